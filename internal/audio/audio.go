@@ -0,0 +1,177 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSampleRate = 44100 // Rate the output runs at, anything else is resampled to it
+	ChannelCount      = 2     // Everything is played back as stereo
+	BytesPerSample    = 2     // Samples are signed 16 bit little endian
+	FrameSize         = ChannelCount * BytesPerSample
+)
+
+var ErrNotSeekable = errors.New("stream cannot be seeked")
+
+// Decoded audio ready to be played. Reads return interleaved stereo 16 bit little endian PCM at SampleRate.
+type Stream interface {
+	io.Reader
+	SampleRate() int
+}
+
+// Stream that knows how long it is and can be moved around in. Offsets and the length are in bytes of the decoded PCM
+// the same as the mp3 decoder, with a length of -1 when it isn't known.
+type SeekableStream interface {
+	Stream
+	io.Seeker
+	Length() int64
+}
+
+// Controls for a single stream being played on an AudioOutput. Matches the parts of oto.Player the app uses so the
+// oto players can be handed back as they are.
+type Player interface {
+	Play()
+	Pause()
+	IsPlaying() bool
+	Volume() float64
+	SetVolume(volume float64)
+	UnplayedBufferSize() int
+	Err() error
+	io.Closer
+
+	Position() time.Duration // How far into the stream has been heard
+	Duration() time.Duration // Length of the whole stream, 0 when it isn't known
+	Seek(position time.Duration) error
+
+	// Carries straight on into another stream once the one being played runs out, see TrackedStream.Chain
+	Chain(next Stream) <-chan struct{}
+	Unchain() bool
+}
+
+// Somewhere to send audio. There is only meant to be one of these for the life of the app, every song is played
+// through it rather than each opening the sound card for itself.
+type AudioOutput interface {
+	SampleRate() int
+	NewPlayer(stream Stream) Player
+}
+
+// Wraps the stream in a resampler when it doesn't already match the output and keeps count of what has been read
+func StreamFor(output AudioOutput, stream Stream) *TrackedStream {
+	return &TrackedStream{source: atRate(stream, output.SampleRate()), sampleRate: output.SampleRate()}
+}
+
+func atRate(stream Stream, sampleRate int) Stream {
+	if stream.SampleRate() != sampleRate {
+		return NewResampler(stream, stream.SampleRate(), sampleRate)
+	}
+	return stream
+}
+
+// Stream at the output's rate that remembers how many bytes have been read out of it. The players read ahead of what
+// is being heard, so they take what they still have buffered off of this to get the position.
+type TrackedStream struct {
+	sampleRate int
+
+	mu       sync.Mutex
+	source   Stream // Swapped for next by Read, so only touched with mu held
+	read     int64
+	next     Stream        // Read on into once source runs out, so the player never hears a gap between the two
+	switched chan struct{} // Closed once next has taken over from source
+}
+
+// Reads and seeks both hold the lock so a seek never lands in the middle of a read
+func (stream *TrackedStream) Read(p []byte) (int, error) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	n, err := stream.source.Read(p)
+	stream.read += int64(n)
+	if errors.Is(err, io.EOF) && stream.next != nil {
+		// Counting starts again from the new stream. Whatever of the old one is still buffered gets taken off the
+		// same as ever, so the position sits at 0 until the new stream starts being heard.
+		stream.source = stream.next
+		stream.next = nil
+		stream.read = 0
+		close(stream.switched)
+		err = nil
+		if n == 0 {
+			n, err = stream.source.Read(p)
+			stream.read += int64(n)
+		}
+	}
+	return n, err
+}
+
+// Lines up a stream to be read straight on into once this one runs out, replacing any that was lined up before.
+// Returns a channel that is closed once the new stream has taken over.
+func (stream *TrackedStream) Chain(next Stream) <-chan struct{} {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	stream.next = atRate(next, stream.sampleRate)
+	stream.switched = make(chan struct{})
+	return stream.switched
+}
+
+// Takes back the stream lined up by Chain. Returns false if it has already taken over, true otherwise.
+func (stream *TrackedStream) Unchain() bool {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	if stream.next == nil && stream.switched != nil {
+		select {
+		case <-stream.switched:
+			return false
+		default:
+		}
+	}
+	stream.next = nil
+	return true
+}
+
+func (stream *TrackedStream) Seek(offset int64, whence int) (int64, error) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	seeker, ok := stream.source.(io.Seeker)
+	if !ok {
+		return 0, ErrNotSeekable
+	}
+	position, err := seeker.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	stream.read = position
+	return position, nil
+}
+
+// Bytes read so far less the ones that haven't been played yet, as a duration
+func (stream *TrackedStream) Position(unplayed int) time.Duration {
+	stream.mu.Lock()
+	played := stream.read - int64(unplayed)
+	stream.mu.Unlock()
+	return stream.toDuration(played)
+}
+
+func (stream *TrackedStream) Duration() time.Duration {
+	stream.mu.Lock()
+	seekable, ok := stream.source.(SeekableStream)
+	stream.mu.Unlock()
+	if !ok || seekable.Length() < 0 {
+		return 0
+	}
+	return stream.toDuration(seekable.Length())
+}
+
+func (stream *TrackedStream) Offset(position time.Duration) int64 {
+	if position < 0 {
+		position = 0
+	}
+	return int64(position) * int64(stream.sampleRate) / int64(time.Second) * FrameSize
+}
+
+func (stream *TrackedStream) toDuration(bytes int64) time.Duration {
+	if bytes < 0 {
+		return 0
+	}
+	return time.Duration(bytes/FrameSize) * time.Second / time.Duration(stream.sampleRate)
+}
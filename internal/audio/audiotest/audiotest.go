@@ -0,0 +1,41 @@
+// Package audiotest writes the audio files the tests of the packages built on audio play through.
+package audiotest
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+)
+
+// Writes a silent 16 bit stereo WAV file of the given length at the default sample rate
+func WriteWAV(t testing.TB, path string, length time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(path, WAV(length), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The bytes of a silent 16 bit stereo WAV file of the given length at the default sample rate
+func WAV(length time.Duration) []byte {
+	frames := int(length * audio.DefaultSampleRate / time.Second)
+	dataSize := frames * audio.FrameSize
+
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+dataSize))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1)
+	binary.LittleEndian.PutUint16(header[22:], audio.ChannelCount)
+	binary.LittleEndian.PutUint32(header[24:], audio.DefaultSampleRate)
+	binary.LittleEndian.PutUint32(header[28:], audio.DefaultSampleRate*audio.FrameSize)
+	binary.LittleEndian.PutUint16(header[32:], audio.FrameSize)
+	binary.LittleEndian.PutUint16(header[34:], audio.BytesPerSample*8)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(dataSize))
+	return append(header, make([]byte, dataSize)...)
+}
@@ -0,0 +1,313 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const magicSize = 12 // Bytes read from the start of a file to work out its format
+
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// A kind of audio file that can be played. Formats are recognised by the first bytes of the file and fall back to the
+// file extension when nothing matches.
+type Format struct {
+	Name       string
+	Extensions []string // Lower case and including the dot
+	Magic      func(header []byte) bool
+	Decode     func(file io.ReadSeeker) (Stream, error)
+}
+
+var formats []Format
+
+// Adds a format to the ones that can be played. The built in formats register themselves.
+func RegisterFormat(format Format) {
+	formats = append(formats, format)
+}
+
+// Works out which format the file at path is in without decoding any of it
+func DetectFormat(path string) (Format, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Format{}, err
+	}
+	defer file.Close()
+
+	format, _, err := detectFormat(path, file)
+	return format, err
+}
+
+// Works out the format along with where the audio starts. Tagging tools put ID3 tags in front of more than just MP3
+// files so the format is told from what comes after one.
+func detectFormat(path string, file io.ReadSeeker) (Format, int64, error) {
+	readHeader := func() ([]byte, error) {
+		header := make([]byte, magicSize)
+		n, err := io.ReadFull(file, header)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return header[:n], nil
+	}
+	header, err := readHeader()
+	if err != nil {
+		return Format{}, 0, err
+	}
+	start := ID3Size(header)
+	if start > 0 {
+		if _, err := file.Seek(start, io.SeekStart); err != nil {
+			return Format{}, 0, err
+		}
+		if header, err = readHeader(); err != nil {
+			return Format{}, 0, err
+		}
+	}
+
+	for _, format := range formats {
+		if format.Magic != nil && format.Magic(header) {
+			return format, start, nil
+		}
+	}
+	extension := strings.ToLower(filepath.Ext(path))
+	for _, format := range formats {
+		for _, formatExtension := range format.Extensions {
+			if extension == formatExtension {
+				return format, start, nil
+			}
+		}
+	}
+	return Format{}, 0, fmt.Errorf("%s: %w", filepath.Base(path), ErrUnsupportedFormat)
+}
+
+// Size of the ID3v2 tag the header starts with, including its own header and any footer, 0 if it doesn't start with
+// one. The header needs to be at least 10 bytes.
+func ID3Size(header []byte) int64 {
+	if len(header) < 10 || !bytes.HasPrefix(header, []byte("ID3")) {
+		return 0
+	}
+
+	// The size leaves out the header and is stored 7 bits to a byte
+	encoded := binary.BigEndian.Uint32(header[6:10])
+	size := int64(encoded&0x7f | encoded>>1&0x3f80 | encoded>>2&0x1fc000 | encoded>>3&0xfe00000)
+	size += 10
+	if header[5]&0x10 != 0 {
+		size += 10 // Footer
+	}
+	return size
+}
+
+// Whether the file at path is in a format that can be played
+func IsSupported(path string) bool {
+	_, err := DetectFormat(path)
+	return err == nil
+}
+
+// Stream read straight from a file, closing it closes the file
+type FileStream struct {
+	Stream
+	Format Format
+	file   *os.File
+}
+
+func (stream *FileStream) Close() error {
+	return stream.file.Close()
+}
+
+func (stream *FileStream) Seek(offset int64, whence int) (int64, error) {
+	seekable, ok := stream.Stream.(SeekableStream)
+	if !ok {
+		return 0, ErrNotSeekable
+	}
+	return seekable.Seek(offset, whence)
+}
+
+func (stream *FileStream) Length() int64 {
+	seekable, ok := stream.Stream.(SeekableStream)
+	if !ok {
+		return -1
+	}
+	return seekable.Length()
+}
+
+// How long the stream plays for, 0 if it can't be told without reading all of it
+func (stream *FileStream) Duration() time.Duration {
+	length := stream.Length()
+	if length < 0 {
+		return 0
+	}
+	return time.Duration(length/FrameSize) * time.Second / time.Duration(stream.SampleRate())
+}
+
+// Opens the file at path and sets up the decoder for its format
+func OpenFile(path string) (*FileStream, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format, start, err := detectFormat(path, file)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// Decoders only get to see the audio, not any tag in front of it
+	var source io.ReadSeeker = file
+	if start > 0 {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		source = io.NewSectionReader(file, start, info.Size()-start)
+	}
+	stream, err := format.Decode(source)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("decoding %s as %s: %w", filepath.Base(path), format.Name, err)
+	}
+	return &FileStream{
+		Stream: stream,
+		Format: format,
+		file:   file,
+	}, nil
+}
+
+// Turns decoded samples of any layout into the interleaved stereo 16 bit PCM that streams are made of. Decoders fill
+// in a block of samples at a time with nextBlock and this hands them out a frame at a time.
+type pcmStream struct {
+	sampleRate int
+	frames     int64                     // Total frames in the stream, -1 when it isn't known
+	nextBlock  func() ([][]int16, error) // Samples per channel for the next block of frames
+
+	// Moves the decoder to the block holding the frame, returning the frame the block actually starts at
+	seekFrame func(frame int64) (int64, error)
+
+	blocks   *pcmBlockReader
+	buffer   *bufio.Reader
+	position int64 // Bytes read so far
+}
+
+func newPCMStream(
+	sampleRate int,
+	frames int64,
+	nextBlock func() ([][]int16, error),
+	seekFrame func(frame int64) (int64, error),
+) *pcmStream {
+	stream := &pcmStream{
+		sampleRate: sampleRate,
+		frames:     frames,
+		nextBlock:  nextBlock,
+		seekFrame:  seekFrame,
+	}
+	stream.blocks = &pcmBlockReader{stream: stream}
+	stream.buffer = bufio.NewReader(stream.blocks)
+	return stream
+}
+
+func (stream *pcmStream) SampleRate() int {
+	return stream.sampleRate
+}
+
+func (stream *pcmStream) Read(p []byte) (int, error) {
+	n, err := stream.buffer.Read(p)
+	stream.position += int64(n)
+	return n, err
+}
+
+func (stream *pcmStream) Length() int64 {
+	if stream.frames < 0 {
+		return -1
+	}
+	return stream.frames * FrameSize
+}
+
+func (stream *pcmStream) Seek(offset int64, whence int) (int64, error) {
+	if stream.seekFrame == nil || stream.frames < 0 {
+		return 0, ErrNotSeekable
+	}
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += stream.position
+	case io.SeekEnd:
+		offset += stream.Length()
+	}
+	frame := offset / FrameSize
+	if frame < 0 {
+		frame = 0
+	}
+	if frame > stream.frames {
+		frame = stream.frames
+	}
+
+	blockStart, err := stream.seekFrame(frame)
+	if err != nil {
+		return 0, err
+	}
+	stream.blocks.pending = nil
+	stream.buffer.Reset(stream.blocks)
+
+	// The decoder can only land on the start of a block so read through to the frame that was asked for
+	if skip := (frame - blockStart) * FrameSize; skip > 0 {
+		if _, err := io.CopyN(io.Discard, stream.buffer, skip); err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+	}
+	stream.position = frame * FrameSize
+	return stream.position, nil
+}
+
+type pcmBlockReader struct {
+	stream  *pcmStream
+	pending []byte
+}
+
+func (r *pcmBlockReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		channels, err := r.stream.nextBlock()
+		if err != nil {
+			return 0, err
+		}
+		r.pending = interleave(channels)
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// Mono is played on both sides and anything past the first two channels is dropped
+func interleave(channels [][]int16) []byte {
+	if len(channels) == 0 {
+		return nil
+	}
+	left, right := channels[0], channels[0]
+	if len(channels) > 1 {
+		right = channels[1]
+	}
+
+	out := make([]byte, len(left)*FrameSize)
+	for i := range left {
+		binary.LittleEndian.PutUint16(out[i*FrameSize:], uint16(left[i]))
+		binary.LittleEndian.PutUint16(out[i*FrameSize+BytesPerSample:], uint16(right[i]))
+	}
+	return out
+}
+
+// Scales a sample of the given bit depth to 16 bits
+func to16Bit(sample int32, bitsPerSample int) int16 {
+	if bitsPerSample > 16 {
+		return int16(sample >> (bitsPerSample - 16))
+	}
+	return int16(sample << (16 - bitsPerSample))
+}
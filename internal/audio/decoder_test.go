@@ -0,0 +1,141 @@
+package audio_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+	"pomogoro/internal/audio/audiotest"
+)
+
+// Header of an ID3v2.4 tag saying how much comes after it
+func id3Header(size int) []byte {
+	header := []byte("ID3\x04\x00\x00\x00\x00\x00\x00")
+	header[6] = byte(size >> 21 & 0x7f)
+	header[7] = byte(size >> 14 & 0x7f)
+	header[8] = byte(size >> 7 & 0x7f)
+	header[9] = byte(size & 0x7f)
+	return header
+}
+
+// An empty ID3v2.4 tag of the given size after its header, the way tagging tools put one in front of a file
+func id3Tag(size int) []byte {
+	return append(id3Header(size), make([]byte, size)...)
+}
+
+var (
+	mp3Frame  = []byte{0xFF, 0xFB, 0x90, 0x64, 0, 0, 0, 0, 0, 0, 0, 0} // MPEG 1 layer 3 at 128kbps
+	adtsFrame = []byte{0xFF, 0xF1, 0x50, 0x80, 0x02, 0x1F, 0xFC, 0, 0, 0, 0, 0}
+	flacStart = []byte("fLaC\x00\x00\x00\x22\x00\x00\x00\x00")
+	oggStart  = []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00")
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents []byte
+		format   string // Empty when it shouldn't be playable
+	}{
+		{"song.wav", audiotest.WAV(10 * time.Millisecond), "WAV"},
+		{"song.flac", flacStart, "FLAC"},
+		{"song.ogg", oggStart, "Ogg Vorbis"},
+		{"song.mp3", mp3Frame, "MP3"},
+		{"tagged.mp3", append(id3Tag(100), mp3Frame...), "MP3"},
+		{"empty-tag.mp3", append(id3Tag(0), mp3Frame...), "MP3"},
+
+		// The contents win out over the extension
+		{"misnamed.mp3", flacStart, "FLAC"},
+		{"tagged.flac", append(id3Tag(100), flacStart...), "FLAC"},
+		{"no-extension", mp3Frame, "MP3"},
+
+		// AAC shares the MPEG sync bits and a tag in front shouldn't make anything look like an MP3
+		{"song.aac", adtsFrame, ""},
+		{"tagged.aac", append(id3Tag(100), adtsFrame...), ""},
+		{"tagged.m4a", append(id3Tag(100), []byte("\x00\x00\x00\x20ftypM4A ")...), ""},
+		{"notes.txt", []byte("just some text"), ""},
+		{"empty.txt", nil, ""},
+
+		// Nothing recognisable falls back to the extension
+		{"broken.mp3", []byte("not really audio"), "MP3"},
+	}
+	dir := t.TempDir()
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		if err := os.WriteFile(path, test.contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+		format, err := audio.DetectFormat(path)
+		if test.format == "" {
+			if !errors.Is(err, audio.ErrUnsupportedFormat) {
+				t.Errorf("DetectFormat(%s) = %s, %v, want it unsupported", test.name, format.Name, err)
+			}
+			continue
+		}
+		if err != nil || format.Name != test.format {
+			t.Errorf("DetectFormat(%s) = %s, %v, want %s", test.name, format.Name, err, test.format)
+		}
+	}
+}
+
+func TestWAVRoundTrip(t *testing.T) {
+	// Fill the silence with a ramp so any byte out of place shows up
+	contents := audiotest.WAV(50 * time.Millisecond)
+	samples := contents[44:]
+	for i := 0; i+1 < len(samples); i += 2 {
+		binary.LittleEndian.PutUint16(samples[i:], uint16(i*7))
+	}
+
+	for _, prefix := range [][]byte{nil, id3Tag(64)} {
+		path := filepath.Join(t.TempDir(), "ramp.wav")
+		if err := os.WriteFile(path, append(append([]byte{}, prefix...), contents...), 0644); err != nil {
+			t.Fatal(err)
+		}
+		stream, err := audio.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stream.SampleRate() != audio.DefaultSampleRate {
+			t.Fatalf("sample rate %d, want %d", stream.SampleRate(), audio.DefaultSampleRate)
+		}
+		if stream.Duration() != 50*time.Millisecond {
+			t.Fatalf("duration %v, want 50ms", stream.Duration())
+		}
+		decoded, err := io.ReadAll(stream)
+		stream.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, samples) {
+			t.Fatalf("decoded %d bytes that don't match the %d written, tagged %v", len(decoded), len(samples), prefix != nil)
+		}
+	}
+}
+
+func TestID3Size(t *testing.T) {
+	footer := id3Header(300)
+	footer[5] = 0x10
+	tests := []struct {
+		name   string
+		header []byte
+		size   int64
+	}{
+		{"no tag", mp3Frame, 0},
+		{"too short", []byte("ID3\x04"), 0},
+		{"empty", id3Header(0), 10},
+		{"spans bytes", id3Header(300), 310},
+		{"largest", id3Header(1<<28 - 1), 1<<28 - 1 + 10},
+		{"footer", footer, 320},
+	}
+	for _, test := range tests {
+		if size := audio.ID3Size(test.header); size != test.size {
+			t.Errorf("%s: ID3Size = %d, want %d", test.name, size, test.size)
+		}
+	}
+}
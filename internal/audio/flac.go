@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+
+	// FLAC imports
+	"github.com/mewkiz/flac"
+)
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "FLAC",
+		Extensions: []string{".flac"},
+		Magic: func(header []byte) bool {
+			return bytes.HasPrefix(header, []byte("fLaC"))
+		},
+		Decode: decodeFLAC,
+	})
+}
+
+func decodeFLAC(file io.ReadSeeker) (Stream, error) {
+	stream, err := flac.NewSeek(file)
+	if err != nil {
+		return nil, err
+	}
+
+	bitsPerSample := int(stream.Info.BitsPerSample)
+	// A length of 0 in the stream info means it wasn't recorded
+	frames := int64(stream.Info.NSamples)
+	if frames == 0 {
+		frames = -1
+	}
+
+	nextBlock := func() ([][]int16, error) {
+		frame, err := stream.ParseNext()
+		if err != nil {
+			return nil, err
+		}
+
+		channels := make([][]int16, len(frame.Subframes))
+		for i, subframe := range frame.Subframes {
+			channels[i] = make([]int16, len(subframe.Samples))
+			for j, sample := range subframe.Samples {
+				channels[i][j] = to16Bit(sample, bitsPerSample)
+			}
+		}
+		return channels, nil
+	}
+	seekFrame := func(frame int64) (int64, error) {
+		if frame >= frames {
+			// Seeking to the very end isn't allowed so stop on the last sample instead
+			frame = frames - 1
+		}
+		blockStart, err := stream.Seek(uint64(frame))
+		return int64(blockStart), err
+	}
+	return newPCMStream(int(stream.Info.SampleRate), frames, nextBlock, seekFrame), nil
+}
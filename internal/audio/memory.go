@@ -0,0 +1,191 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const memoryChunkSize = 4096
+
+// Output that doesn't need a sound card. Streams are read as fast as they can be and kept in memory so the player can
+// be tested on machines without one.
+type MemoryOutput struct {
+	sampleRate int
+	realTime   bool // Read the streams at the speed they would play instead of as fast as possible
+	discard    bool // Throw the audio away instead of keeping it
+
+	mu      sync.Mutex
+	players []*MemoryPlayer
+}
+
+func NewMemoryOutput(sampleRate int) *MemoryOutput {
+	return &MemoryOutput{sampleRate: sampleRate}
+}
+
+// Output that silently plays everything in real time and keeps none of it. Used in place of the sound card when it
+// can't be opened so songs still take as long as they should.
+func NewNullOutput(sampleRate int) *MemoryOutput {
+	return &MemoryOutput{
+		sampleRate: sampleRate,
+		realTime:   true,
+		discard:    true,
+	}
+}
+
+func (output *MemoryOutput) SampleRate() int {
+	return output.sampleRate
+}
+
+func (output *MemoryOutput) NewPlayer(stream Stream) Player {
+	player := &MemoryPlayer{
+		source:     StreamFor(output, stream),
+		sampleRate: output.sampleRate,
+		realTime:   output.realTime,
+		discard:    output.discard,
+		volume:     1,
+	}
+
+	output.mu.Lock()
+	output.players = append(output.players, player)
+	output.mu.Unlock()
+	return player
+}
+
+// Every player created on the output so far, oldest first
+func (output *MemoryOutput) Players() []*MemoryPlayer {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	return append([]*MemoryPlayer{}, output.players...)
+}
+
+type MemoryPlayer struct {
+	source     *TrackedStream
+	sampleRate int
+	realTime   bool
+	discard    bool
+
+	mu      sync.Mutex
+	played  bytes.Buffer
+	playing bool
+	closed  bool
+	volume  float64
+	err     error
+}
+
+// Starts copying the stream into memory in the background until it ends, is paused or is closed
+func (player *MemoryPlayer) Play() {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	if player.playing || player.closed {
+		return
+	}
+	player.playing = true
+	go player.drain()
+}
+
+func (player *MemoryPlayer) drain() {
+	chunk := make([]byte, memoryChunkSize)
+	for {
+		player.mu.Lock()
+		if !player.playing || player.closed {
+			player.mu.Unlock()
+			return
+		}
+		player.mu.Unlock()
+
+		n, err := player.source.Read(chunk)
+
+		if player.realTime {
+			time.Sleep(time.Duration(n/FrameSize) * time.Second / time.Duration(player.sampleRate))
+		}
+
+		player.mu.Lock()
+		if !player.discard {
+			player.played.Write(chunk[:n])
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				player.err = err
+			}
+			player.playing = false
+			player.mu.Unlock()
+			return
+		}
+		player.mu.Unlock()
+	}
+}
+
+func (player *MemoryPlayer) Pause() {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	player.playing = false
+}
+
+func (player *MemoryPlayer) IsPlaying() bool {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.playing
+}
+
+func (player *MemoryPlayer) Volume() float64 {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.volume
+}
+
+func (player *MemoryPlayer) SetVolume(volume float64) {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	player.volume = volume
+}
+
+// Nothing is ever buffered since the stream is written straight to memory
+func (player *MemoryPlayer) UnplayedBufferSize() int {
+	return 0
+}
+
+func (player *MemoryPlayer) Err() error {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.err
+}
+
+func (player *MemoryPlayer) Close() error {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	player.playing = false
+	player.closed = true
+	return nil
+}
+
+// Nothing is buffered so everything read has been played
+func (player *MemoryPlayer) Position() time.Duration {
+	return player.source.Position(0)
+}
+
+func (player *MemoryPlayer) Duration() time.Duration {
+	return player.source.Duration()
+}
+
+func (player *MemoryPlayer) Seek(position time.Duration) error {
+	_, err := player.source.Seek(player.source.Offset(position), io.SeekStart)
+	return err
+}
+
+func (player *MemoryPlayer) Chain(next Stream) <-chan struct{} {
+	return player.source.Chain(next)
+}
+
+func (player *MemoryPlayer) Unchain() bool {
+	return player.source.Unchain()
+}
+
+// Copy of everything that has been played so far
+func (player *MemoryPlayer) Played() []byte {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return append([]byte{}, player.played.Bytes()...)
+}
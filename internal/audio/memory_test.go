@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// Stream of raw PCM held in memory
+type bytesStream struct {
+	*bytes.Reader
+	sampleRate int
+}
+
+func (stream bytesStream) SampleRate() int {
+	return stream.sampleRate
+}
+
+func (stream bytesStream) Length() int64 {
+	return stream.Size()
+}
+
+func newBytesStream(frames int, sampleRate int) bytesStream {
+	pcm := make([]byte, frames*FrameSize)
+	for i := range pcm {
+		pcm[i] = byte(i)
+	}
+	return bytesStream{Reader: bytes.NewReader(pcm), sampleRate: sampleRate}
+}
+
+// Waits for the player to read its stream to the end
+func waitUntilPlayed(t *testing.T, player Player) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for player.IsPlaying() {
+		if time.Now().After(deadline) {
+			t.Fatal("player did not finish the stream")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMemoryOutputPlaysWholeStream(t *testing.T) {
+	output := NewMemoryOutput(DefaultSampleRate)
+	stream := newBytesStream(DefaultSampleRate/2, DefaultSampleRate)
+	want, _ := io.ReadAll(io.NewSectionReader(stream.Reader, 0, stream.Size()))
+
+	player := output.NewPlayer(stream)
+	if player.Duration() != 500*time.Millisecond {
+		t.Fatalf("got a duration of %v, want 500ms", player.Duration())
+	}
+	player.Play()
+	waitUntilPlayed(t, player)
+
+	if err := player.Err(); err != nil {
+		t.Fatal(err)
+	}
+	played := output.Players()[0].Played()
+	if !bytes.Equal(played, want) {
+		t.Fatalf("played %d bytes that differ from the %d in the stream", len(played), len(want))
+	}
+	if player.Position() != 500*time.Millisecond {
+		t.Fatalf("got a position of %v after playing it all, want 500ms", player.Position())
+	}
+}
+
+func TestMemoryOutputResamplesToItsRate(t *testing.T) {
+	output := NewMemoryOutput(DefaultSampleRate)
+	player := output.NewPlayer(newBytesStream(22050, 22050))
+	player.Play()
+	waitUntilPlayed(t, player)
+
+	// A second of audio is still a second long once it is at the output's rate
+	frames := len(output.Players()[0].Played()) / FrameSize
+	if frames < DefaultSampleRate-2 || frames > DefaultSampleRate+2 {
+		t.Fatalf("got %d frames from a second at 22050Hz, want about %d", frames, DefaultSampleRate)
+	}
+}
+
+func TestMemoryPlayerSeek(t *testing.T) {
+	output := NewMemoryOutput(DefaultSampleRate)
+	player := output.NewPlayer(newBytesStream(DefaultSampleRate, DefaultSampleRate))
+
+	if err := player.Seek(250 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if player.Position() != 250*time.Millisecond {
+		t.Fatalf("got a position of %v after seeking, want 250ms", player.Position())
+	}
+	player.Play()
+	waitUntilPlayed(t, player)
+
+	if played := len(output.Players()[0].Played()); played != DefaultSampleRate*3/4*FrameSize {
+		t.Fatalf("played %d bytes after seeking a quarter in, want %d", played, DefaultSampleRate*3/4*FrameSize)
+	}
+}
@@ -0,0 +1,33 @@
+package audio
+
+import (
+	"io"
+
+	// MP3 imports
+	"github.com/hajimehoshi/go-mp3"
+)
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "MP3",
+		Extensions: []string{".mp3"},
+		Magic:      isMP3,
+		Decode: func(file io.ReadSeeker) (Stream, error) {
+			// The mp3 decoder already produces stereo 16 bit PCM
+			return mp3.NewDecoder(file)
+		},
+	})
+}
+
+// The header of an MPEG audio frame, which any ID3 tag has already been skipped past to get to. AAC in ADTS starts
+// with the same sync bits but always has the layer set to 0, which isn't a layer MPEG audio uses.
+func isMP3(header []byte) bool {
+	if len(header) < 4 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return false
+	}
+	version := header[1] >> 3 & 0x3
+	layer := header[1] >> 1 & 0x3
+	bitrate := header[2] >> 4
+	sampleRate := header[2] >> 2 & 0x3
+	return version != 1 && layer != 0 && bitrate != 0xF && sampleRate != 3
+}
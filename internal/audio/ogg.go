@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"math"
+
+	// Ogg Vorbis imports
+	"github.com/jfreymuth/oggvorbis"
+)
+
+const oggBlockFrames = 4096 // Frames decoded at a time
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "Ogg Vorbis",
+		Extensions: []string{".ogg", ".oga"},
+		Magic: func(header []byte) bool {
+			return bytes.HasPrefix(header, []byte("OggS"))
+		},
+		Decode: decodeOgg,
+	})
+}
+
+func decodeOgg(file io.ReadSeeker) (Stream, error) {
+	reader, err := oggvorbis.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	channelCount := reader.Channels()
+	samples := make([]float32, oggBlockFrames*channelCount)
+	// The reader only knows its length when the file can be seeked, which it always can be here
+	frames := reader.Length()
+	if frames == 0 {
+		frames = -1
+	}
+
+	nextBlock := func() ([][]int16, error) {
+		n, err := reader.Read(samples)
+		if n == 0 {
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+
+		// The samples come interleaved as floats between -1 and 1
+		frames := n / channelCount
+		channels := make([][]int16, channelCount)
+		for channel := range channels {
+			channels[channel] = make([]int16, frames)
+			for frame := 0; frame < frames; frame++ {
+				sample := math.Max(-1, math.Min(1, float64(samples[frame*channelCount+channel])))
+				channels[channel][frame] = int16(sample * math.MaxInt16)
+			}
+		}
+		return channels, nil
+	}
+	seekFrame := func(frame int64) (int64, error) {
+		// The reader skips through to the exact sample itself
+		return frame, reader.SetPosition(frame)
+	}
+	return newPCMStream(reader.SampleRate(), frames, nextBlock, seekFrame), nil
+}
@@ -0,0 +1,154 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// Converts a stream from one sample rate to another by interpolating linearly between neighbouring frames. It's not
+// studio quality but is more than good enough for music in the background.
+type Resampler struct {
+	source   Stream
+	buffered *bufio.Reader
+	fromRate int
+	toRate   int
+	step     float64 // Source frames to move forward for every frame written
+
+	position float64 // How far between current and next the output is, from 0 up to 1
+	current  [ChannelCount]int16
+	next     [ChannelCount]int16
+	started  bool
+	done     bool
+	err      error
+	written  int64 // Bytes written out so far, used to seek relative to the current position
+
+	pending []byte // Part of a frame that didn't fit in the last read
+}
+
+func NewResampler(source Stream, fromRate int, toRate int) *Resampler {
+	return &Resampler{
+		source:   source,
+		buffered: bufio.NewReader(source),
+		fromRate: fromRate,
+		toRate:   toRate,
+		step:     float64(fromRate) / float64(toRate),
+	}
+}
+
+func (r *Resampler) SampleRate() int {
+	return r.toRate
+}
+
+func (r *Resampler) Read(p []byte) (int, error) {
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	var frame [FrameSize]byte
+	for n < len(p) {
+		if !r.nextFrame(frame[:]) {
+			break
+		}
+		copied := copy(p[n:], frame[:])
+		r.pending = append(r.pending, frame[copied:]...)
+		n += copied
+	}
+	r.written += int64(n)
+
+	if n == 0 && r.done {
+		return 0, r.err
+	}
+	return n, nil
+}
+
+// Length of the resampled stream in bytes, or -1 if the source doesn't know its length
+func (r *Resampler) Length() int64 {
+	source, ok := r.source.(SeekableStream)
+	if !ok || source.Length() < 0 {
+		return -1
+	}
+	return r.toOutputFrames(source.Length()/FrameSize) * FrameSize
+}
+
+// Seeks the source to the matching spot and starts interpolating again from there
+func (r *Resampler) Seek(offset int64, whence int) (int64, error) {
+	source, ok := r.source.(SeekableStream)
+	if !ok {
+		return 0, ErrNotSeekable
+	}
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.written
+	case io.SeekEnd:
+		offset += r.Length()
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	frame := offset / FrameSize
+
+	sourceFrame := frame * int64(r.fromRate) / int64(r.toRate)
+	if _, err := source.Seek(sourceFrame*FrameSize, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r.buffered.Reset(source)
+	r.position = 0
+	r.started = false
+	r.done = false
+	r.err = nil
+	r.pending = nil
+	r.written = frame * FrameSize
+	return r.written, nil
+}
+
+func (r *Resampler) toOutputFrames(sourceFrames int64) int64 {
+	return sourceFrames * int64(r.toRate) / int64(r.fromRate)
+}
+
+// Writes the next output frame, returning false once the source has run out
+func (r *Resampler) nextFrame(frame []byte) bool {
+	if !r.started {
+		r.started = true
+		if !r.readSourceFrame(&r.current) || !r.readSourceFrame(&r.next) {
+			return false
+		}
+	}
+	if r.done {
+		return false
+	}
+
+	for channel := 0; channel < ChannelCount; channel++ {
+		from, to := float64(r.current[channel]), float64(r.next[channel])
+		sample := int16(from + (to-from)*r.position)
+		binary.LittleEndian.PutUint16(frame[channel*BytesPerSample:], uint16(sample))
+	}
+
+	r.position += r.step
+	for r.position >= 1 {
+		r.position -= 1
+		r.current = r.next
+		if !r.readSourceFrame(&r.next) {
+			// Nothing left to interpolate towards, this was the last frame
+			break
+		}
+	}
+	return true
+}
+
+func (r *Resampler) readSourceFrame(frame *[ChannelCount]int16) bool {
+	var raw [FrameSize]byte
+	if _, err := io.ReadFull(r.buffered, raw[:]); err != nil {
+		// A trailing partial frame is dropped the same as the end of the stream
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		r.done = true
+		r.err = err
+		return false
+	}
+	for channel := 0; channel < ChannelCount; channel++ {
+		frame[channel] = int16(binary.LittleEndian.Uint16(raw[channel*BytesPerSample:]))
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+// Output that plays through the sound card. It is kept apart from the rest of the audio package since linking oto needs
+// the system's sound libraries, which tests and anything else that only needs the in memory outputs can do without.
+package speaker
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+
+	// Audio imports
+	"github.com/hajimehoshi/oto/v2"
+)
+
+// Output that plays through the sound card. oto only allows a single context per process so this has to be created
+// once at startup and shared.
+type OtoOutput struct {
+	context    *oto.Context
+	sampleRate int
+}
+
+func NewOtoOutput(sampleRate int) (*OtoOutput, error) {
+	context, ready, err := oto.NewContext(sampleRate, audio.ChannelCount, audio.BytesPerSample)
+	if err != nil {
+		return nil, fmt.Errorf("opening audio output: %w", err)
+	}
+	<-ready
+
+	return &OtoOutput{
+		context:    context,
+		sampleRate: sampleRate,
+	}, nil
+}
+
+func (output *OtoOutput) SampleRate() int {
+	return output.sampleRate
+}
+
+func (output *OtoOutput) NewPlayer(stream audio.Stream) audio.Player {
+	tracked := audio.StreamFor(output, stream)
+	return &otoPlayer{
+		Player: output.context.NewPlayer(tracked),
+		stream: tracked,
+	}
+}
+
+// The oto player with the position and seeking filled in from the stream it is reading
+type otoPlayer struct {
+	oto.Player
+	stream *audio.TrackedStream
+}
+
+func (player *otoPlayer) Position() time.Duration {
+	return player.stream.Position(player.UnplayedBufferSize())
+}
+
+func (player *otoPlayer) Duration() time.Duration {
+	return player.stream.Duration()
+}
+
+// The oto player drops what it has buffered and seeks the stream itself
+func (player *otoPlayer) Seek(position time.Duration) error {
+	_, err := player.Player.(io.Seeker).Seek(player.stream.Offset(position), io.SeekStart)
+	return err
+}
+
+func (player *otoPlayer) Chain(next audio.Stream) <-chan struct{} {
+	return player.stream.Chain(next)
+}
+
+func (player *otoPlayer) Unchain() bool {
+	return player.stream.Unchain()
+}
@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatExtensible = 0xFFFE
+	wavBlockFrames      = 4096 // Frames read at a time
+)
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "WAV",
+		Extensions: []string{".wav", ".wave"},
+		Magic: func(header []byte) bool {
+			return len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE"))
+		},
+		Decode: decodeWAV,
+	})
+}
+
+// Details from the fmt chunk of a WAV file
+type wavFormat struct {
+	AudioFormat   uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// Reads uncompressed PCM WAV files of 8, 16, 24 or 32 bits
+func decodeWAV(file io.ReadSeeker) (Stream, error) {
+	var riffHeader [12]byte
+	if _, err := io.ReadFull(file, riffHeader[:]); err != nil {
+		return nil, err
+	}
+
+	// Walk the chunks until the audio data, picking up the format on the way
+	var format *wavFormat
+	for {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(file, chunkHeader[:]); err != nil {
+			return nil, fmt.Errorf("no audio data found: %w", err)
+		}
+		id := string(chunkHeader[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+
+		switch id {
+		case "fmt ":
+			format = &wavFormat{}
+			if err := binary.Read(file, binary.LittleEndian, format); err != nil {
+				return nil, err
+			}
+			size -= int64(binary.Size(format))
+		case "data":
+			if format == nil {
+				return nil, errors.New("audio data comes before the format")
+			}
+			start, err := file.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			return newWAVStream(file, start, size, format)
+		}
+
+		// Chunks are padded out to an even number of bytes
+		if _, err := file.Seek(size+size%2, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func newWAVStream(file io.ReadSeeker, start int64, size int64, format *wavFormat) (Stream, error) {
+	if format.AudioFormat != wavFormatPCM && format.AudioFormat != wavFormatExtensible {
+		return nil, fmt.Errorf("compressed WAV files are not supported")
+	}
+	bitsPerSample := int(format.BitsPerSample)
+	if bitsPerSample != 8 && bitsPerSample != 16 && bitsPerSample != 24 && bitsPerSample != 32 {
+		return nil, fmt.Errorf("%d bit WAV files are not supported", bitsPerSample)
+	}
+	if format.Channels == 0 {
+		return nil, errors.New("WAV file has no channels")
+	}
+
+	channelCount := int(format.Channels)
+	bytesPerSample := bitsPerSample / 8
+	bytesPerFrame := int64(channelCount * bytesPerSample)
+	block := make([]byte, wavBlockFrames*bytesPerFrame)
+	data := io.LimitReader(file, size)
+
+	nextBlock := func() ([][]int16, error) {
+		n, err := io.ReadFull(data, block)
+		frames := n / int(bytesPerFrame)
+		if frames == 0 {
+			if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+				err = io.EOF
+			}
+			return nil, err
+		}
+
+		channels := make([][]int16, channelCount)
+		for channel := range channels {
+			channels[channel] = make([]int16, frames)
+			for frame := 0; frame < frames; frame++ {
+				offset := (frame*channelCount + channel) * bytesPerSample
+				channels[channel][frame] = wavSample(block[offset:offset+bytesPerSample], bitsPerSample)
+			}
+		}
+		return channels, nil
+	}
+	seekFrame := func(frame int64) (int64, error) {
+		offset := frame * bytesPerFrame
+		if _, err := file.Seek(start+offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		data = io.LimitReader(file, size-offset)
+		return frame, nil
+	}
+	return newPCMStream(int(format.SampleRate), size/bytesPerFrame, nextBlock, seekFrame), nil
+}
+
+func wavSample(raw []byte, bitsPerSample int) int16 {
+	switch bitsPerSample {
+	case 8:
+		// 8 bit samples are the only unsigned ones
+		return int16(int(raw[0])-128) << 8
+	case 16:
+		return int16(binary.LittleEndian.Uint16(raw))
+	case 24:
+		sample := int32(raw[0]) | int32(raw[1])<<8 | int32(int8(raw[2]))<<16
+		return to16Bit(sample, 24)
+	default:
+		return to16Bit(int32(binary.LittleEndian.Uint32(raw)), 32)
+	}
+}
@@ -0,0 +1,127 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Source of time for anything that counts down. Swapping the real clock out for the fake one lets the timer be driven
+// without actually waiting.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Fires once on C after its duration has passed unless it is stopped first
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool // Reports whether the timer was stopped before it fired
+}
+
+type realClock struct{}
+
+func NewRealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (timer realTimer) C() <-chan time.Time {
+	return timer.Timer.C
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	until time.Time
+	ch    chan time.Time
+}
+
+func (timer *fakeTimer) C() <-chan time.Time {
+	return timer.ch
+}
+
+// Takes the timer out of the waiters so it no longer counts towards BlockUntil
+func (timer *fakeTimer) Stop() bool {
+	fc := timer.clock
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for i, waiter := range fc.waiters {
+		if waiter == timer {
+			fc.waiters = append(fc.waiters[:i], fc.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Clock that only moves when told to with Advance
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeTimer // Timers that are still waiting to fire
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	fc := &FakeClock{now: start}
+	fc.cond = sync.NewCond(&fc.mu)
+	return fc
+}
+
+func (fc *FakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.now
+}
+
+func (fc *FakeClock) NewTimer(d time.Duration) Timer {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	// Buffered so Advance never blocks on a waiter that gave up listening
+	timer := &fakeTimer{clock: fc, until: fc.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.ch <- fc.now
+		return timer
+	}
+	fc.waiters = append(fc.waiters, timer)
+	fc.cond.Broadcast()
+	return timer
+}
+
+// Moves the clock forward and fires every waiter whose time has come
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.now = fc.now.Add(d)
+	pending := fc.waiters[:0]
+	for _, waiter := range fc.waiters {
+		if !waiter.until.After(fc.now) {
+			waiter.ch <- fc.now
+		} else {
+			pending = append(pending, waiter)
+		}
+	}
+	fc.waiters = pending
+}
+
+// Blocks until at least n timers are waiting to fire. Used to make sure a goroutine has gone to sleep before moving the
+// clock forward. Timers that have been stopped don't count.
+func (fc *FakeClock) BlockUntil(n int) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for len(fc.waiters) < n {
+		fc.cond.Wait()
+	}
+}
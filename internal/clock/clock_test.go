@@ -0,0 +1,60 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockFiresTimersOnceDue(t *testing.T) {
+	fc := NewFakeClock(time.Unix(0, 0))
+	early := fc.NewTimer(time.Second)
+	late := fc.NewTimer(2 * time.Second)
+
+	fc.Advance(time.Second)
+	select {
+	case <-early.C():
+	default:
+		t.Fatal("timer due after a second did not fire")
+	}
+	select {
+	case <-late.C():
+		t.Fatal("timer due after two seconds fired early")
+	default:
+	}
+
+	fc.Advance(time.Second)
+	select {
+	case <-late.C():
+	default:
+		t.Fatal("timer due after two seconds did not fire")
+	}
+}
+
+func TestFakeClockBlockUntilIgnoresStoppedTimers(t *testing.T) {
+	fc := NewFakeClock(time.Unix(0, 0))
+	stopped := fc.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Fatal("Stop reported a waiting timer as already fired")
+	}
+	if stopped.Stop() {
+		t.Fatal("Stop reported a stopped timer as still waiting")
+	}
+
+	blocked := make(chan struct{})
+	go func() {
+		fc.BlockUntil(1)
+		close(blocked)
+	}()
+	select {
+	case <-blocked:
+		t.Fatal("BlockUntil returned with only a stopped timer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	fc.NewTimer(time.Second)
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("BlockUntil did not return once a timer was waiting")
+	}
+}
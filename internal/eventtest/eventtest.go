@@ -0,0 +1,50 @@
+// Package eventtest gathers up the events published while a test runs so what happened can be checked afterwards.
+package eventtest
+
+import (
+	"sync"
+	"testing"
+)
+
+// Keeps every event passed to Record, from whichever goroutines they are published on
+type Recorder[T any] struct {
+	mu     sync.Mutex
+	events []T
+}
+
+// Meant to be subscribed to whatever publishes the events
+func (recorder *Recorder[T]) Record(event T) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.events = append(recorder.events, event)
+}
+
+// Every event recorded so far, oldest first
+func (recorder *Recorder[T]) Events() []T {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return append([]T{}, recorder.events...)
+}
+
+// Picks something out of each of the events it matches, such as the names of the songs that were started
+func Collect[T any, V any](events []T, pick func(T) (V, bool)) []V {
+	var picked []V
+	for _, event := range events {
+		if value, ok := pick(event); ok {
+			picked = append(picked, value)
+		}
+	}
+	return picked
+}
+
+func AssertEqual[T comparable](t testing.TB, got []T, want []T) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	// Internal imports
+	"pomogoro/internal/song"
+
+	// Gui imports
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+const mixedText = "(mixed)" // Shown in place of a field that differs between the songs being edited
+
+// A field shared by every song in the batch. It is only written to them if it gets changed.
+type batchField struct {
+	Input   *widget.Entry
+	initial string
+	mixed   bool // The songs didn't agree on the value to begin with
+	touched bool // Typed in at all, which is the only way to tell clearing a mixed field from leaving it be
+	get     func(song.Details) string
+	set     func(*song.Details, string)
+}
+
+func newBatchField(songs []*song.Song, get func(song.Details) string, set func(*song.Details, string)) *batchField {
+	field := &batchField{
+		Input: widget.NewEntry(),
+		get:   get,
+		set:   set,
+	}
+
+	// Fill in the value if the songs agree on it, otherwise leave it empty and say they don't
+	for i, batchSong := range songs {
+		value := get(batchSong.Details())
+		if i == 0 {
+			field.initial = value
+		} else if value != field.initial {
+			field.mixed = true
+		}
+	}
+	if field.mixed {
+		field.initial = ""
+		field.Input.SetPlaceHolder(mixedText)
+	}
+	field.Input.SetText(field.initial)
+	field.Input.OnChanged = func(string) {
+		if field.mixed && !field.touched {
+			// Once typed in the field is written to every song, even if it is left empty
+			field.Input.SetPlaceHolder("")
+		}
+		field.touched = true
+	}
+	return field
+}
+
+func (field *batchField) changed() bool {
+	if field.mixed {
+		return field.touched
+	}
+	return field.Input.Text != field.initial
+}
+
+type BatchEditWindow struct {
+	Window      fyne.Window
+	Container   *fyne.Container
+	ApplyButton *widget.Button
+	ProgressBar *widget.ProgressBar
+
+	fields []*batchField
+	songs  []*song.Song
+}
+
+// Builds the window to set the artist, album, genre and year of several songs at once. onSaved is run once they have
+// all been saved.
+func NewBatchEditWindow(app fyne.App, songs []*song.Song, onSaved func()) *BatchEditWindow {
+	batchWindow := app.NewWindow(fmt.Sprintf("Edit %d Songs", len(songs)))
+
+	artistField := newBatchField(songs,
+		func(details song.Details) string { return details.Artist },
+		func(details *song.Details, value string) { details.Artist = value },
+	)
+	albumField := newBatchField(songs,
+		func(details song.Details) string { return details.Album },
+		func(details *song.Details, value string) { details.Album = value },
+	)
+	genreField := newBatchField(songs,
+		func(details song.Details) string { return details.Genre },
+		func(details *song.Details, value string) { details.Genre = value },
+	)
+	yearField := newBatchField(songs,
+		func(details song.Details) string { return details.Year },
+		func(details *song.Details, value string) { details.Year = value },
+	)
+	yearField.Input.Validator = song.ValidateYear
+
+	progressBar := widget.NewProgressBar()
+	progressBar.Max = float64(len(songs))
+	progressBar.Hide()
+
+	b := &BatchEditWindow{
+		Window:      batchWindow,
+		ProgressBar: progressBar,
+		fields:      []*batchField{artistField, albumField, genreField, yearField},
+		songs:       songs,
+	}
+	b.ApplyButton = widget.NewButton("Apply", func() {
+		b.apply(onSaved)
+	})
+	cancelButton := widget.NewButton("Cancel", batchWindow.Close)
+
+	fieldsContainer := container.New(
+		layout.NewFormLayout(),
+		widget.NewLabel("Artist"), artistField.Input,
+		widget.NewLabel("Album"), albumField.Input,
+		widget.NewLabel("Genre"), genreField.Input,
+		widget.NewLabel("Year"), yearField.Input,
+	)
+	b.Container = container.New(
+		layout.NewVBoxLayout(),
+		widget.NewLabel(fmt.Sprintf("Fields left as %s keep each song's own value.", mixedText)),
+		fieldsContainer,
+		progressBar,
+		container.New(layout.NewHBoxLayout(), layout.NewSpacer(), cancelButton, b.ApplyButton),
+	)
+	return b
+}
+
+func (b *BatchEditWindow) Render() {
+	b.Window.SetContent(b.Container)
+	b.Window.Resize(fyne.NewSize(400, 300))
+	b.Window.Show()
+}
+
+// Saves the changed fields to every song in the background, showing how far along it is
+func (b *BatchEditWindow) apply(onSaved func()) {
+	// The values are copied out here since the entries can't be read from the goroutine saving the songs
+	changed := map[*batchField]string{}
+	for _, field := range b.fields {
+		if field.changed() {
+			changed[field] = field.Input.Text
+		}
+	}
+	if len(changed) == 0 {
+		b.Window.Close()
+		return
+	}
+	edit := func(details song.Details) song.Details {
+		for field, value := range changed {
+			field.set(&details, value)
+		}
+		return details
+	}
+
+	b.ApplyButton.Disable()
+	b.ProgressBar.SetValue(0)
+	b.ProgressBar.Show()
+	go func() {
+		err := song.SaveDetailsBatch(b.songs, edit, func(done int, total int) {
+			b.ProgressBar.SetValue(float64(done))
+		})
+		b.ProgressBar.Hide()
+		b.ApplyButton.Enable()
+		if err != nil {
+			b.showFailures(err)
+			return
+		}
+
+		if onSaved != nil {
+			onSaved()
+		}
+		b.Window.Close()
+	}()
+}
+
+// Lists every song that couldn't be saved along with why
+func (b *BatchEditWindow) showFailures(err error) {
+	var batchErr *song.BatchError
+	if !errors.As(err, &batchErr) {
+		dialog.ShowError(err, b.Window)
+		return
+	}
+
+	var report strings.Builder
+	if len(batchErr.RollbackFailures) > 0 {
+		report.WriteString("These songs couldn't be saved, so the others were put back how they were:\n")
+	} else {
+		report.WriteString("None of the songs were changed because these couldn't be saved:\n")
+	}
+	for _, failure := range batchErr.Failures {
+		fmt.Fprintf(&report, "\n%s: %v", failure.Song.Name, failure.Err)
+	}
+	if len(batchErr.RollbackFailures) > 0 {
+		report.WriteString("\n\nExcept for these, which were saved but couldn't be put back and keep the new details:\n")
+		for _, failure := range batchErr.RollbackFailures {
+			fmt.Fprintf(&report, "\n%s: %v", failure.Song.Name, failure.Err)
+		}
+	}
+
+	reportText := widget.NewLabel(report.String())
+	reportText.Wrapping = fyne.TextWrapWord
+	reportScroll := container.NewVScroll(reportText)
+	reportScroll.SetMinSize(fyne.NewSize(350, 200))
+	dialog.ShowCustom("Could Not Save Songs", "Close", reportScroll, b.Window)
+}
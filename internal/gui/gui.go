@@ -3,19 +3,28 @@ package gui
 import (
 	"fmt"
 	"log"
+	"math"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	// Internal imports
+	"pomogoro/internal/history"
 	"pomogoro/internal/library"
 	"pomogoro/internal/music"
 	"pomogoro/internal/player"
 	"pomogoro/internal/pomoapp"
 	"pomogoro/internal/pomodoro"
+	"pomogoro/internal/song"
 
 	// Gui imports
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
@@ -29,9 +38,11 @@ func NewGui(
 	app fyne.App,
 	pomodoroTimer *pomodoro.PomodoroTimer,
 	appSettings *pomoapp.Settings,
+	presets *pomodoro.PresetStore,
+	historyStore *history.Store,
 	library *music.Library,
 ) *Gui {
-	toolbar := CreateNewToolbar(app, pomodoroTimer, appSettings)
+	toolbar := CreateNewToolbar(app, pomodoroTimer, appSettings, presets, historyStore, nil)
 	return &Gui{
 		Toolbar: toolbar,
 	}
@@ -43,59 +54,163 @@ type PomodoroCreationWindow struct {
 	FocusTimeInput     *widget.Entry
 	RelaxTimeInput     *widget.Entry
 	IterationTimeInput *widget.Entry
+
+	LongBreakTimeInput     *widget.Entry
+	LongBreakIntervalInput *widget.Entry
+	CountFinalBreakInput   *widget.Check
+	PresetNameInput        *widget.Entry
 }
 
-func NewPomodoroCreationWindow(app fyne.App, p *pomodoro.PomodoroTimer) *PomodoroCreationWindow {
+// Builds the window to create a new timer. Passing in a preset fills the form with its values so it can be edited.
+func NewPomodoroCreationWindow(
+	app fyne.App,
+	p *pomodoro.PomodoroTimer,
+	presets *pomodoro.PresetStore,
+	preset *pomodoro.Preset,
+) *PomodoroCreationWindow {
 	// This will initialize and build the window and provide links to the fields that would be used to retrieve input
 	// or modify values elsewhere
-	pomodoroWindow := app.NewWindow("New Pomodoro")
-	focusTimeLabel := widget.NewLabel("Enter focus time in minutes: ")
-	focusTimeLabelContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
-		focusTimeLabel,
-	)
+	windowTitle := "New Pomodoro"
+	if preset != nil {
+		windowTitle = "Edit Preset"
+	}
+	pomodoroWindow := app.NewWindow(windowTitle)
+
+	// Times can be typed as minutes (25), minutes and seconds (25:00) or a duration (25m, 1h30m, 90s)
+	validateDuration := func(text string) error {
+		_, err := pomodoro.ParseDuration(text)
+		return err
+	}
 	focusTimeText := widget.NewEntry()
-	focusTimeTextContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
-		focusTimeText,
-	)
+	focusTimeText.Validator = validateDuration
+	focusTimeText.SetText("25m")
+	focusTimeRow := newFormRow("Focus time (25m, 1h30m, 90s): ", focusTimeText)
 
-	relaxTimeLabel := widget.NewLabel("Enter relax time in minutes: ")
-	relaxTimeLabelContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
-		relaxTimeLabel,
-	)
 	relaxTimeText := widget.NewEntry()
-	relaxTimeTextContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
-		relaxTimeText,
-	)
+	relaxTimeText.Validator = validateDuration
+	relaxTimeText.SetText("5m")
+	relaxTimeRow := newFormRow("Relax time: ", relaxTimeText)
+
+	longBreakTimeText := widget.NewEntry()
+	longBreakTimeText.SetText("15m")
+	longBreakTimeRow := newFormRow("Long break time: ", longBreakTimeText)
+
+	longBreakIntervalText := widget.NewEntry()
+	longBreakIntervalText.Validator = func(text string) error {
+		_, err := pomodoro.ParseLongBreakInterval(text)
+		return err
+	}
+	longBreakIntervalText.SetText("4")
+	longBreakTimeText.Validator = func(text string) error {
+		// Without long breaks how long they are doesn't matter, a preset without them is saved with 0
+		if interval, err := pomodoro.ParseLongBreakInterval(longBreakIntervalText.Text); err == nil && interval == 0 {
+			return nil
+		}
+		return validateDuration(text)
+	}
+	longBreakIntervalText.OnChanged = func(string) {
+		longBreakTimeText.Validate()
+	}
+	longBreakIntervalRow := newFormRow("Take a long break every N focus periods (0 for never): ", longBreakIntervalText)
+
+	countFinalBreakCheckBox := widget.NewCheck("Count down the break after the final focus period", nil)
+	countFinalBreakCheckBox.Checked = true
 
-	iterationTimeLabel := widget.NewLabel("Enter the number of iterations to complete: ")
-	iterationTimeLabelContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
-		iterationTimeLabel,
-	)
 	iterationTimeText := widget.NewEntry()
-	iterationTimeTextContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
-		iterationTimeText,
-	)
+	iterationTimeText.Validator = func(text string) error {
+		_, err := pomodoro.ParseIterations(text)
+		return err
+	}
+	iterationTimeText.SetText("4")
+	iterationTimeRow := newFormRow("Enter the number of iterations to complete: ", iterationTimeText)
+
+	presetNameText := widget.NewEntry()
+	presetNameText.SetPlaceHolder("Preset name")
+	presetNameRow := newFormRow("Save as preset (optional): ", presetNameText)
+
+	// Fill in the form when editing an existing preset
+	if preset != nil {
+		focusTimeText.SetText(pomodoro.FormatDuration(preset.Settings.StartFocusTime))
+		relaxTimeText.SetText(pomodoro.FormatDuration(preset.Settings.StartRelaxTime))
+		longBreakTimeText.SetText(pomodoro.FormatDuration(preset.Settings.StartLongBreakTime))
+		longBreakIntervalText.SetText(strconv.Itoa(preset.Settings.LongBreakInterval))
+		iterationTimeText.SetText(strconv.Itoa(preset.Settings.Iterations))
+		countFinalBreakCheckBox.Checked = preset.Settings.CountFinalBreak
+		presetNameText.SetText(preset.Name)
+	}
 
 	createTimerButton := widget.NewButton("Create Timer", func() {
+		// Run every validator so each bad entry shows its message, not just the first one found
+		valid := true
+		for _, entry := range []*widget.Entry{
+			focusTimeText,
+			relaxTimeText,
+			longBreakTimeText,
+			longBreakIntervalText,
+			iterationTimeText,
+		} {
+			if err := entry.Validate(); err != nil {
+				valid = false
+			}
+		}
+		if !valid {
+			return
+		}
+
 		dialog.ShowConfirm(
 			"Confirm",
 			"Do you want to create this timer? Current timer will be overridden",
 			func(confirm bool) {
+				if !confirm {
+					return
+				}
 				fmt.Println("Starting new Pomodoro Timer")
-				// TODO(map) Error handling here
-				focusTime, _ := strconv.Atoi(focusTimeText.Text)
-				relaxTime, _ := strconv.Atoi(relaxTimeText.Text)
-				iterationTime, _ := strconv.Atoi(iterationTimeText.Text)
-				p.SetSettings(focusTime, relaxTime, iterationTime)
-				p.RestartTimer()
-				p.UpdateTimerText()
-				p.UpdateIterationText()
+				// The entries were all validated before confirming so these can't fail
+				focusTime, _ := pomodoro.ParseDuration(focusTimeText.Text)
+				relaxTime, _ := pomodoro.ParseDuration(relaxTimeText.Text)
+				longBreakTime, _ := pomodoro.ParseDuration(longBreakTimeText.Text)
+				longBreakInterval, _ := pomodoro.ParseLongBreakInterval(longBreakIntervalText.Text)
+				iterationTime, _ := pomodoro.ParseIterations(iterationTimeText.Text)
+				pomodoroSettings := pomodoro.NewPomodoroSettings(
+					focusTime,
+					relaxTime,
+					longBreakTime,
+					longBreakInterval,
+					iterationTime,
+					countFinalBreakCheckBox.Checked,
+				)
+
+				// Without a name the timer is a one off so there is nothing to save
+				presetName := strings.TrimSpace(presetNameText.Text)
+				if presetName == "" {
+					p.SetSettings(pomodoroSettings)
+					p.RestartTimer()
+					pomodoroWindow.Close()
+					return
+				}
+
+				newPreset := pomodoro.Preset{Name: presetName, Settings: pomodoroSettings}
+				var err error
+				if preset == nil {
+					// A new timer mustn't quietly replace a saved one that happens to share its name
+					err = presets.Add(newPreset)
+				} else {
+					// Keep the linked playlists and carry the rename over to the default as well
+					newPreset.FocusPlaylist = preset.FocusPlaylist
+					newPreset.RelaxPlaylist = preset.RelaxPlaylist
+					if preset.Name != newPreset.Name {
+						if err := presets.Rename(preset.Name, newPreset.Name); err != nil {
+							dialog.ShowError(err, pomodoroWindow)
+							return
+						}
+					}
+					err = presets.Put(newPreset)
+				}
+				if err != nil {
+					dialog.ShowError(err, pomodoroWindow)
+					return
+				}
+				p.ApplyPreset(newPreset)
 				pomodoroWindow.Close()
 			},
 			pomodoroWindow,
@@ -106,18 +221,17 @@ func NewPomodoroCreationWindow(app fyne.App, p *pomodoro.PomodoroTimer) *Pomodor
 		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
 		createTimerButton,
 	)
-	textContainer := container.NewVBox(
-		focusTimeLabelContainer,
-		relaxTimeLabelContainer,
-		iterationTimeLabelContainer,
-	)
-	inputContainer := container.NewVBox(
-		focusTimeTextContainer,
-		relaxTimeTextContainer,
-		iterationTimeTextContainer,
+	content := container.New(
+		layout.NewVBoxLayout(),
+		focusTimeRow,
+		relaxTimeRow,
+		longBreakTimeRow,
+		longBreakIntervalRow,
+		iterationTimeRow,
+		presetNameRow,
+		countFinalBreakCheckBox,
+		timerButtonContainer,
 	)
-	pomodoroInfoContainer := container.New(layout.NewHBoxLayout(), textContainer, inputContainer)
-	content := container.New(layout.NewVBoxLayout(), pomodoroInfoContainer, timerButtonContainer)
 
 	return &PomodoroCreationWindow{
 		Window:             pomodoroWindow,
@@ -125,27 +239,116 @@ func NewPomodoroCreationWindow(app fyne.App, p *pomodoro.PomodoroTimer) *Pomodor
 		FocusTimeInput:     focusTimeText,
 		RelaxTimeInput:     relaxTimeText,
 		IterationTimeInput: iterationTimeText,
+
+		LongBreakTimeInput:     longBreakTimeText,
+		LongBreakIntervalInput: longBreakIntervalText,
+		CountFinalBreakInput:   countFinalBreakCheckBox,
+		PresetNameInput:        presetNameText,
 	}
 }
 
 func (p *PomodoroCreationWindow) Render() {
 	p.Window.SetContent(p.Container)
-	p.Window.Resize(fyne.NewSize(400, 400))
+	p.Window.Resize(fyne.NewSize(450, 500))
 	p.Window.Show()
 }
 
+// Lays out a labelled entry with room underneath for its validation message, which is only shown while the entry
+// holds something invalid
+func newFormRow(labelText string, entry *widget.Entry) *fyne.Container {
+	label := widget.NewLabel(labelText)
+	label.Wrapping = fyne.TextWrapWord
+	labelContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
+		label,
+	)
+	entryContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(200, 40)),
+		entry,
+	)
+
+	errorText := canvas.NewText("", theme.ErrorColor())
+	errorText.TextSize = theme.CaptionTextSize()
+	errorText.Hide()
+	entry.SetOnValidationChanged(func(err error) {
+		if err == nil {
+			errorText.Hide()
+			return
+		}
+		errorText.Text = err.Error()
+		errorText.Show()
+		errorText.Refresh()
+	})
+
+	return container.New(
+		layout.NewVBoxLayout(),
+		container.New(layout.NewHBoxLayout(), labelContainer, entryContainer),
+		errorText,
+	)
+}
+
 type SettingsWindow struct {
 	Window    fyne.Window
 	Container *fyne.Container
 }
 
-func NewSettingsWindow(app fyne.App, s *pomoapp.Settings) *SettingsWindow {
+// Builds the window to change the settings. onSaved is run in the background once they have been saved so the library
+// can pick up any change to its folders or patterns.
+func NewSettingsWindow(app fyne.App, s *pomoapp.Settings, onSaved func()) *SettingsWindow {
 	settingsWindow := app.NewWindow("Settings")
 
 	// Widget creation
-	libraryPathLabel := widget.NewLabel("Library path: ")
-	libraryPath := widget.NewEntry()
-	libraryPath.SetText(s.LibraryPath)
+	libraryRootsLabel := widget.NewLabel("Library folders: ")
+	libraryRoots := append([]string{}, s.Roots()...)
+	var libraryRootsList *widget.List
+	libraryRootsList = widget.NewList(
+		func() int {
+			return len(libraryRoots)
+		},
+		func() fyne.CanvasObject {
+			removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			return container.NewBorder(nil, nil, nil, removeButton, widget.NewLabel(""))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(libraryRoots[i])
+			row.Objects[1].(*widget.Button).OnTapped = func() {
+				libraryRoots = append(libraryRoots[:i], libraryRoots[i+1:]...)
+				libraryRootsList.Refresh()
+			}
+		})
+	addRootButton := widget.NewButtonWithIcon("Add Folder", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, settingsWindow)
+				return
+			}
+			if folder == nil {
+				// Cancelled
+				return
+			}
+			for _, root := range libraryRoots {
+				if root == folder.Path() {
+					return
+				}
+			}
+			libraryRoots = append(libraryRoots, folder.Path())
+			libraryRootsList.Refresh()
+		}, settingsWindow)
+	})
+
+	// Patterns are typed as a comma separated list
+	validatePatterns := func(text string) error {
+		return library.ValidatePatterns(splitPatterns(text))
+	}
+	includePatterns := widget.NewEntry()
+	includePatterns.SetPlaceHolder("*.mp3, *.flac")
+	includePatterns.SetText(strings.Join(s.IncludePatterns, ", "))
+	includePatterns.Validator = validatePatterns
+	excludePatterns := widget.NewEntry()
+	excludePatterns.SetPlaceHolder("Podcasts/*")
+	excludePatterns.SetText(strings.Join(s.ExcludePatterns, ", "))
+	excludePatterns.Validator = validatePatterns
 	autoPlayCheckBox := widget.NewCheck("Autoplay next song", func(checked bool) {
 		s.AutoPlay = checked
 	})
@@ -161,18 +364,45 @@ func NewSettingsWindow(app fyne.App, s *pomoapp.Settings) *SettingsWindow {
 		},
 	)
 	linkPlayersCheckBox.Checked = s.LinkPlayers
+	crossfadeLabel := widget.NewLabel(crossfadeText(s.CrossfadeSeconds))
+	crossfadeSlider := widget.NewSlider(0, pomoapp.MaxCrossfadeSeconds)
+	crossfadeSlider.Step = 1
+	crossfadeSlider.Value = float64(s.CrossfadeSeconds)
+	crossfadeSlider.OnChanged = func(value float64) {
+		crossfadeLabel.SetText(crossfadeText(int(value)))
+	}
+	gaplessCheckBox := widget.NewCheck("Gapless playback", nil)
+	gaplessCheckBox.Checked = s.Gapless
 
 	saveButton := widget.NewButton("Save", func() {
+		if err := validatePatterns(includePatterns.Text); err != nil {
+			dialog.ShowError(err, settingsWindow)
+			return
+		}
+		if err := validatePatterns(excludePatterns.Text); err != nil {
+			dialog.ShowError(err, settingsWindow)
+			return
+		}
 		dialog.ShowConfirm(
 			"Confirm",
 			"Are you sure you want to save these settings?",
 			func(confirm bool) {
+				if !confirm {
+					return
+				}
 				s.Save(
-					libraryPath.Text,
+					libraryRoots,
+					splitPatterns(includePatterns.Text),
+					splitPatterns(excludePatterns.Text),
 					autoPlayCheckBox.Checked,
 					shuffleCheckBox.Checked,
 					linkPlayersCheckBox.Checked,
+					int(crossfadeSlider.Value),
+					gaplessCheckBox.Checked,
 				)
+				if onSaved != nil {
+					go onSaved()
+				}
 				settingsWindow.Close()
 			},
 			settingsWindow,
@@ -180,16 +410,34 @@ func NewSettingsWindow(app fyne.App, s *pomoapp.Settings) *SettingsWindow {
 	})
 
 	// Create the rows
-	libraySettingsRow := container.New(layout.NewVBoxLayout(), libraryPathLabel, libraryPath)
+	libraySettingsRow := container.New(
+		layout.NewVBoxLayout(),
+		container.New(layout.NewHBoxLayout(), libraryRootsLabel, layout.NewSpacer(), addRootButton),
+		container.New(layout.NewGridWrapLayout(fyne.NewSize(500, 120)), libraryRootsList),
+		newFormRow("Include patterns:", includePatterns),
+		newFormRow("Exclude patterns:", excludePatterns),
+	)
 	playSettingsRow := container.New(
 		layout.NewHBoxLayout(),
 		autoPlayCheckBox,
 		shuffleCheckBox,
 		linkPlayersCheckBox,
 	)
+	transitionSettingsRow := container.New(
+		layout.NewHBoxLayout(),
+		crossfadeLabel,
+		container.New(layout.NewGridWrapLayout(fyne.NewSize(150, 40)), crossfadeSlider),
+		gaplessCheckBox,
+	)
 	saveRow := container.New(layout.NewGridWrapLayout(fyne.NewSize(50, 50)), saveButton)
 
-	content := container.New(layout.NewVBoxLayout(), libraySettingsRow, playSettingsRow, saveRow)
+	content := container.New(
+		layout.NewVBoxLayout(),
+		libraySettingsRow,
+		playSettingsRow,
+		transitionSettingsRow,
+		saveRow,
+	)
 
 	return &SettingsWindow{
 		Window:    settingsWindow,
@@ -197,142 +445,450 @@ func NewSettingsWindow(app fyne.App, s *pomoapp.Settings) *SettingsWindow {
 	}
 }
 
+// Splits a comma separated list of patterns, dropping any blank ones
+func splitPatterns(text string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(text, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func crossfadeText(seconds int) string {
+	if seconds == 0 {
+		return "Crossfade: Off"
+	}
+	return fmt.Sprintf("Crossfade: %ds", seconds)
+}
+
 func (p *SettingsWindow) Render() {
 	p.Window.SetContent(p.Container)
-	p.Window.Resize(fyne.NewSize(400, 400))
+	p.Window.Resize(fyne.NewSize(550, 600))
 	p.Window.Show()
 }
 
 type LibraryView struct {
-	LibraryList     *widget.List
-	Container       *fyne.Container
-	Library         *library.Library
-	SongDetailsView *SongDetailsView
+	LibraryList          *widget.List
+	RescanButton         *widget.Button
+	EditSelectedButton   *widget.Button
+	ClearSelectionButton *widget.Button
+	Container            *fyne.Container
+	Library              *library.Library
+	SongDetailsView      *SongDetailsView
+
+	refreshing atomic.Bool // Set while the list is catching up with the library rather than being picked from
+
+	// Songs ticked in the list to be edited together
+	checkedMu sync.Mutex
+	checked   map[*song.Song]bool
 }
 
 func NewLibraryView(
 	labelText string,
 	library *library.Library,
 	songDetailsView *SongDetailsView,
-	settings *pomoapp.Settings,
 	player *player.Player,
+	window fyne.Window,
+	rescan func() error,
 ) *LibraryView {
+	l := &LibraryView{
+		Library:         library,
+		SongDetailsView: songDetailsView,
+		checked:         map[*song.Song]bool{},
+	}
 	libraryListLabel := widget.NewLabel(labelText)
+
+	// Reads every file in the library again in case the cache has gotten out of step with them
+	var rescanButton *widget.Button
+	rescanButton = widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		rescanButton.Disable()
+		go func() {
+			defer rescanButton.Enable()
+			if err := rescan(); err != nil {
+				dialog.ShowError(err, window)
+			}
+		}()
+	})
 	libraryList := widget.NewList(
 		func() int {
-			return len(library.Songs)
+			return len(library.Songs())
 		},
 		func() fyne.CanvasObject {
-			return widget.NewLabel(library.CurrentSong.Name)
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil), nil, newSongListItem(library.Queue))
 		},
 		func(i widget.ListItemID, o fyne.CanvasObject) {
-			o.(*widget.Label).SetText(library.Songs[i].Name)
+			row := o.(*fyne.Container)
+			rowSong, ok := library.Song(i)
+			if !ok {
+				// The library shrank since the list last asked how long it is, a refresh of the list is on its way
+				return
+			}
+			row.Objects[0].(*songListItem).SetSong(rowSong)
+
+			// Set the tick before listening to it so reusing the row doesn't tick the wrong song
+			check := row.Objects[1].(*widget.Check)
+			check.OnChanged = nil
+			check.SetChecked(l.isChecked(rowSong))
+			check.OnChanged = func(checked bool) {
+				l.setChecked(rowSong, checked)
+			}
 		})
+	editSelectedButton := widget.NewButtonWithIcon("Edit Selected", theme.DocumentCreateIcon(), func() {
+		songs := l.CheckedSongs()
+		if len(songs) == 0 {
+			return
+		}
+		NewBatchEditWindow(fyne.CurrentApp(), songs, func() {
+			l.UpdateSelected()
+		}).Render()
+	})
+	clearSelectionButton := widget.NewButton("Clear", func() {
+		l.checkedMu.Lock()
+		l.checked = map[*song.Song]bool{}
+		l.checkedMu.Unlock()
+		l.updateSelectionButtons()
+		libraryList.Refresh()
+	})
+
 	libraryListLabelContainer := container.New(
 		layout.NewGridWrapLayout(fyne.NewSize(50, 50)),
 		libraryListLabel,
+		rescanButton,
 	)
 	libraryListContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(300, 400)),
-		libraryList,
+		layout.NewVBoxLayout(),
+		container.New(layout.NewGridWrapLayout(fyne.NewSize(300, 350)), libraryList),
+		container.New(layout.NewHBoxLayout(), editSelectedButton, clearSelectionButton),
 	)
 
 	// Initialize and refresh right away because the first song should be selected
-	l := LibraryView{
-		LibraryList: libraryList,
-		Container: container.New(
-			layout.NewHBoxLayout(),
-			libraryListLabelContainer,
-			libraryListContainer,
-			songDetailsView.Container,
-		),
-		Library:         library,
-		SongDetailsView: songDetailsView,
-	}
+	l.LibraryList = libraryList
+	l.RescanButton = rescanButton
+	l.EditSelectedButton = editSelectedButton
+	l.ClearSelectionButton = clearSelectionButton
+	l.Container = container.New(
+		layout.NewHBoxLayout(),
+		libraryListLabelContainer,
+		libraryListContainer,
+		songDetailsView.Container,
+	)
 	l.UpdateSelected()
+	l.updateSelectionButtons()
 
 	libraryList.OnSelected = func(index int) {
+		if l.refreshing.Load() {
+			// The same song moved in the list so there's nothing to change
+			return
+		}
+
 		// Kill the currently playing song
-		if library.CurrentSong.Player != nil && library.CurrentSong.Player.IsPlaying() {
-			library.CurrentSong.Stop(false) // TODO(map) Is this right?
+		if currentSong := library.CurrentSong(); currentSong != nil && currentSong.IsPlaying() {
+			currentSong.Stop(false) // TODO(map) Is this right?
 		}
 
 		// Update the index and new song and start playing
-		library.CurrIdx = index
-		library.CurrentSong = library.Songs[library.CurrIdx]
+		library.Select(index)
+		l.UpdateSelected()
+		player.PlaySong(library.CurrentSong())
+	}
+
+	// Show new titles as soon as they are saved
+	songDetailsView.OnSaved = func(*song.Song) {
+		libraryList.Refresh()
+	}
+
+	// Follow songs being added to and removed from the library without interrupting the one playing
+	library.Subscribe(func() {
+		l.forgetRemovedSongs()
+		l.refreshing.Store(true)
 		l.UpdateSelected()
-		go library.CurrentSong.Play(player.SongControlChan)
+		l.refreshing.Store(false)
+	})
+
+	return l
+}
+
+// Songs ticked in the list, in the order they are in the library
+func (l *LibraryView) CheckedSongs() []*song.Song {
+	var songs []*song.Song
+	for _, librarySong := range l.Library.Songs() {
+		if l.isChecked(librarySong) {
+			songs = append(songs, librarySong)
+		}
+	}
+	return songs
+}
+
+func (l *LibraryView) isChecked(librarySong *song.Song) bool {
+	l.checkedMu.Lock()
+	defer l.checkedMu.Unlock()
+	return l.checked[librarySong]
+}
+
+func (l *LibraryView) setChecked(librarySong *song.Song, checked bool) {
+	l.checkedMu.Lock()
+	if checked {
+		l.checked[librarySong] = true
+	} else {
+		delete(l.checked, librarySong)
+	}
+	l.checkedMu.Unlock()
+	l.updateSelectionButtons()
+}
+
+// Unticks songs that have left the library
+func (l *LibraryView) forgetRemovedSongs() {
+	inLibrary := map[*song.Song]bool{}
+	for _, librarySong := range l.Library.Songs() {
+		inLibrary[librarySong] = true
 	}
+	l.checkedMu.Lock()
+	for checkedSong := range l.checked {
+		if !inLibrary[checkedSong] {
+			delete(l.checked, checkedSong)
+		}
+	}
+	l.checkedMu.Unlock()
+	l.updateSelectionButtons()
+}
+
+func (l *LibraryView) updateSelectionButtons() {
+	l.checkedMu.Lock()
+	count := len(l.checked)
+	l.checkedMu.Unlock()
 
-	return &l
+	if count == 0 {
+		l.EditSelectedButton.SetText("Edit Selected")
+		l.EditSelectedButton.Disable()
+		l.ClearSelectionButton.Disable()
+		return
+	}
+	l.EditSelectedButton.SetText(fmt.Sprintf("Edit %d Selected", count))
+	l.EditSelectedButton.Enable()
+	l.ClearSelectionButton.Enable()
 }
 
 func (l *LibraryView) UpdateSelected() {
-	l.LibraryList.Select(l.Library.CurrIdx)
+	songs := l.Library.Songs()
+	currentSong := l.Library.CurrentSong()
+	currIdx := l.Library.CurrentIndex()
+	if currIdx >= 0 && currIdx < len(songs) && songs[currIdx] == currentSong {
+		l.LibraryList.Select(currIdx)
+	} else {
+		// The current song has been taken out of the library
+		l.LibraryList.UnselectAll()
+	}
 	l.LibraryList.Refresh()
 
-	currentSong := l.Library.CurrentSong
-	l.SongDetailsView.TitleInput.SetText(currentSong.Tag.Title())
-	l.SongDetailsView.ArtistInput.SetText(currentSong.Tag.Artist())
-	l.SongDetailsView.AlbumInput.SetText(currentSong.Tag.Album())
-	l.SongDetailsView.GenreInput.SetText(currentSong.Tag.Genre())
-	l.SongDetailsView.TitleInput.Refresh()
-	l.SongDetailsView.ArtistInput.Refresh()
-	l.SongDetailsView.AlbumInput.Refresh()
-	l.SongDetailsView.GenreInput.Refresh()
+	l.SongDetailsView.ShowSong(currentSong)
 }
 
 type SongDetailsView struct {
 	Container *fyne.Container
 
-	// TODO(map) Include labels for these guys
-	TitleInput  *widget.Entry
-	ArtistInput *widget.Entry
-	AlbumInput  *widget.Entry
-	GenreInput  *widget.Entry
+	TitleInput   *widget.Entry
+	ArtistInput  *widget.Entry
+	AlbumInput   *widget.Entry
+	GenreInput   *widget.Entry
+	TrackInput   *widget.Entry
+	YearInput    *widget.Entry
+	CommentInput *widget.Entry
+	SaveButton   *widget.Button
+	RevertButton *widget.Button
+
+	CurrentSong *song.Song
+	OnSaved     func(savedSong *song.Song) // Run once the song's tags have been written to its file
 
-	CurrentSong *music.Song
+	saved  song.Details // What the song's tags held when they were last shown or saved
+	window fyne.Window
 }
 
-func NewSongDetailsView(labelText string) *SongDetailsView {
+func NewSongDetailsView(labelText string, window fyne.Window) *SongDetailsView {
 	detailsLabel := widget.NewLabel(labelText)
 	titleInput := widget.NewEntry()
 	artistInput := widget.NewEntry()
 	albumInput := widget.NewEntry()
 	genreInput := widget.NewEntry()
+	trackInput := widget.NewEntry()
+	trackInput.SetPlaceHolder("3/12")
+	trackInput.Validator = song.ValidateTrack
+	yearInput := widget.NewEntry()
+	yearInput.SetPlaceHolder("1997")
+	yearInput.Validator = song.ValidateYear
+	commentInput := widget.NewEntry()
 
-	saveId3DataButton := widget.NewButton("Save", func() {
-		fmt.Println("TODO(map) Implement the song save here")
-	})
+	d := &SongDetailsView{
+		TitleInput:   titleInput,
+		ArtistInput:  artistInput,
+		AlbumInput:   albumInput,
+		GenreInput:   genreInput,
+		TrackInput:   trackInput,
+		YearInput:    yearInput,
+		CommentInput: commentInput,
+		window:       window,
+	}
+	d.SaveButton = widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), d.Save)
+	d.RevertButton = widget.NewButtonWithIcon("Revert", theme.ContentUndoIcon(), d.Revert)
+	for _, input := range d.inputs() {
+		input.OnChanged = func(string) {
+			d.updateButtons()
+		}
+	}
 
+	fieldsContainer := container.New(
+		layout.NewFormLayout(),
+		widget.NewLabel("Title"), titleInput,
+		widget.NewLabel("Artist"), artistInput,
+		widget.NewLabel("Album"), albumInput,
+		widget.NewLabel("Genre"), genreInput,
+		widget.NewLabel("Track"), trackInput,
+		widget.NewLabel("Year"), yearInput,
+		widget.NewLabel("Comment"), commentInput,
+	)
 	songDetailsContainer := container.New(
 		layout.NewVBoxLayout(),
 		detailsLabel,
-		titleInput,
-		artistInput,
-		albumInput,
-		genreInput,
-		saveId3DataButton,
+		fieldsContainer,
+		container.New(layout.NewHBoxLayout(), d.SaveButton, d.RevertButton),
 	)
 
-	return &SongDetailsView{
-		Container: container.New(
-			layout.NewGridWrapLayout(fyne.NewSize(300, 400)),
-			songDetailsContainer,
-		),
-		TitleInput:  titleInput,
-		ArtistInput: artistInput,
-		AlbumInput:  albumInput,
-		GenreInput:  genreInput,
+	d.Container = container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(300, 400)),
+		container.NewVScroll(songDetailsContainer),
+	)
+	d.ShowSong(nil)
+	return d
+}
+
+func (d *SongDetailsView) inputs() []*widget.Entry {
+	return []*widget.Entry{
+		d.TitleInput,
+		d.ArtistInput,
+		d.AlbumInput,
+		d.GenreInput,
+		d.TrackInput,
+		d.YearInput,
+		d.CommentInput,
+	}
+}
+
+// Fills the inputs in from the song's tags. Edits that haven't been saved yet are kept if it is the song already
+// being shown, such as when the library refreshes underneath it.
+func (d *SongDetailsView) ShowSong(shownSong *song.Song) {
+	if shownSong != nil && shownSong == d.CurrentSong && d.edited() {
+		return
+	}
+	d.CurrentSong = shownSong
+	d.saved = song.Details{}
+	if shownSong != nil {
+		d.saved = shownSong.Details()
+	}
+	d.Revert()
+
+	for _, input := range d.inputs() {
+		if shownSong == nil {
+			input.Disable()
+		} else {
+			input.Enable()
+		}
+	}
+}
+
+// The details as they are typed into the inputs
+func (d *SongDetailsView) Details() song.Details {
+	return song.Details{
+		Title:   d.TitleInput.Text,
+		Artist:  d.ArtistInput.Text,
+		Album:   d.AlbumInput.Text,
+		Genre:   d.GenreInput.Text,
+		Track:   d.TrackInput.Text,
+		Year:    d.YearInput.Text,
+		Comment: d.CommentInput.Text,
+	}
+}
+
+// Writes what is in the inputs to the song's file
+func (d *SongDetailsView) Save() {
+	if d.CurrentSong == nil {
+		return
+	}
+	details := d.Details()
+	if err := details.Validate(); err != nil {
+		dialog.ShowError(err, d.window)
+		return
+	}
+	if err := d.CurrentSong.SaveDetails(details); err != nil {
+		log.Println("Could not save the song details: ", err)
+		dialog.ShowError(err, d.window)
+		return
+	}
+
+	d.saved = details
+	d.updateButtons()
+	if d.OnSaved != nil {
+		d.OnSaved(d.CurrentSong)
+	}
+}
+
+// Throws away any edits, going back to what the song's tags hold
+func (d *SongDetailsView) Revert() {
+	d.TitleInput.SetText(d.saved.Title)
+	d.ArtistInput.SetText(d.saved.Artist)
+	d.AlbumInput.SetText(d.saved.Album)
+	d.GenreInput.SetText(d.saved.Genre)
+	d.TrackInput.SetText(d.saved.Track)
+	d.YearInput.SetText(d.saved.Year)
+	d.CommentInput.SetText(d.saved.Comment)
+	d.updateButtons()
+}
+
+func (d *SongDetailsView) edited() bool {
+	return d.Details() != d.saved
+}
+
+// Saving and reverting only make sense once something has been changed
+func (d *SongDetailsView) updateButtons() {
+	if d.SaveButton == nil {
+		// Still being built
+		return
+	}
+	if d.CurrentSong != nil && d.edited() {
+		d.SaveButton.Enable()
+		d.RevertButton.Enable()
+	} else {
+		d.SaveButton.Disable()
+		d.RevertButton.Disable()
 	}
 }
 
+const progressInterval = 500 * time.Millisecond // How often the song progress is refreshed
+
 type MusicControls struct {
 	Container  *fyne.Container
 	PrevButton *widget.Button
 	PlayButton *widget.Button
 	StopButton *widget.Button
 	NextButton *widget.Button
+
+	// Cycles between not repeating, repeating the library and repeating the song
+	RepeatButton *widget.Button
+
+	// Volume
+	MuteButton   *widget.Button
+	VolumeSlider *widget.Slider
+
+	// Progress through the current song
+	ProgressSlider *widget.Slider
+	ElapsedLabel   *widget.Label
+	RemainingLabel *widget.Label
+
+	updatingProgress atomic.Bool // Set while the slider is being moved to match the song rather than by the user
+	scrubbing        atomic.Bool // Set while the user is dragging the slider
+
+	player   *player.Player
+	settings *pomoapp.Settings
 }
 
 func NewMusicControls(
@@ -343,21 +899,25 @@ func NewMusicControls(
 ) *MusicControls {
 	prevButton := widget.NewButton("Prev", func() {
 		log.Println("Prev clicked")
-		if library.CurrIdx == 0 {
-			// Do nothing because we can't decrement
+		if !library.HasPrevious(settings) {
+			// Do nothing because there is nothing to go back to
 			fmt.Println("Cannot go to previous song")
-		} else if settings.Shuffle {
-			library.CurrentSong.Stop(true)
-			library.NextShuffle()
 		} else {
-			library.CurrentSong.Stop(true)
-			library.DecIndex()
+			library.CurrentSong().Stop(true)
+			library.Previous(settings)
 		}
 	})
 	playButton := widget.NewButton("Play", func() {
 		log.Println("Play clicked")
+		current := library.CurrentSong()
+		if current == nil {
+			// Nothing in the library to play
+			log.Println("No songs to play")
+			return
+		}
+
 		// Case where there is no Player set because the initial launch of the MP3 hasn't happened
-		if library.CurrentSong.Player == nil {
+		if !current.IsLoaded() {
 			log.Println("No song set, playing song...")
 
 			// Start the player
@@ -365,29 +925,31 @@ func NewMusicControls(
 
 			// Start the pomodoro timer if the timer and music controls are linked
 			if settings.LinkPlayers {
-				go pomodoroTimer.StartTimer()
+				pomodoroTimer.StartTimer()
 			}
-		} else if library.CurrentSong.Player.IsPlaying() { // Case of song is currently playing
+		} else if current.IsPlaying() { // Case of song is currently playing
 			log.Println("Pausing song...")
-			library.CurrentSong.Pause(player.SongControlChan)
+			current.Pause(player.Events)
 
 			// Pause the pomodoro timer if the timer and music controls are linked
 			if settings.LinkPlayers {
 				pomodoroTimer.PauseTimer()
 			}
-		} else if !library.CurrentSong.Player.IsPlaying() { // Case where song is paused
+		} else { // Case where song is paused
 			log.Println("Resuming song...")
-			library.CurrentSong.Resume(player.SongControlChan)
+			current.Resume(player.Events)
 
 			// Resume the pomodoro timer if the timer and music controls are linked
 			if settings.LinkPlayers {
-				go pomodoroTimer.StartTimer()
+				pomodoroTimer.StartTimer()
 			}
 		}
 	})
 	stopButton := widget.NewButton("Stop", func() {
 		log.Println("Stop clicked")
-		library.CurrentSong.Stop(false)
+		if current := library.CurrentSong(); current != nil {
+			current.Stop(false)
+		}
 
 		// Pause the pomodoro timer if the timer and music controls are linked
 		if settings.LinkPlayers {
@@ -396,23 +958,40 @@ func NewMusicControls(
 	})
 	nextButton := widget.NewButton("Next", func() {
 		log.Println("Next clicked")
-		if !library.HasNextSong {
-			// Do nothing because we can't decrement
+		if !library.HasNext(settings) {
+			// Do nothing because there is nothing queued or left in the library
 			fmt.Println("Cannot go to next song")
-		} else if settings.Shuffle {
-			library.CurrentSong.Stop(true)
-			library.NextShuffle()
 		} else {
-			library.CurrentSong.Stop(true)
-			library.IncIndex()
+			library.CurrentSong().Stop(true)
+			library.Next(settings)
 		}
 	})
 
+	repeatButton := widget.NewButtonWithIcon(settings.Playback().Repeat.String(), theme.MediaReplayIcon(), nil)
+	repeatButton.OnTapped = func() {
+		repeatButton.SetText(settings.CycleRepeat().String())
+	}
+
+	// Volume is shown as a percentage on the slider but stored between 0 and 1
+	muteButton := widget.NewButtonWithIcon("", theme.VolumeUpIcon(), nil)
+	volumeSlider := widget.NewSlider(0, 100)
+	volumeSlider.Step = 1
+	volumeSlider.Value = settings.VolumeLevel() * 100
+
+	// Position in the song in seconds
+	progressSlider := widget.NewSlider(0, 1)
+	progressSlider.Step = 1
+	elapsedLabel := widget.NewLabel(formatTrackTime(0))
+	remainingLabel := widget.NewLabel(formatTrackTime(0))
+
 	// Containers around the buttons to ensure their size doesn't grow beyond what is desired
 	prevButtonContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(50, 50)), prevButton)
 	playButtonContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(50, 50)), playButton)
 	stopButtonContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(50, 50)), stopButton)
 	nextButtonContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(50, 50)), nextButton)
+	repeatButtonContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(80, 50)), repeatButton)
+	muteButtonContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(50, 50)), muteButton)
+	volumeSliderContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(150, 50)), volumeSlider)
 
 	// Control
 	controlsRow := container.New(
@@ -421,28 +1000,178 @@ func NewMusicControls(
 		playButtonContainer,
 		stopButtonContainer,
 		nextButtonContainer,
+		repeatButtonContainer,
+		muteButtonContainer,
+		volumeSliderContainer,
+	)
+	progressRow := container.New(
+		layout.NewHBoxLayout(),
+		elapsedLabel,
+		container.New(layout.NewGridWrapLayout(fyne.NewSize(400, 40)), progressSlider),
+		remainingLabel,
 	)
 
-	return &MusicControls{
-		Container:  container.New(layout.NewCenterLayout(), controlsRow),
-		PrevButton: prevButton,
+	mc := &MusicControls{
+		Container: container.New(
+			layout.NewVBoxLayout(),
+			container.New(layout.NewCenterLayout(), controlsRow),
+			container.New(layout.NewCenterLayout(), progressRow),
+		),
+		PrevButton:     prevButton,
+		PlayButton:     playButton,
+		StopButton:     stopButton,
+		NextButton:     nextButton,
+		RepeatButton:   repeatButton,
+		MuteButton:     muteButton,
+		VolumeSlider:   volumeSlider,
+		ProgressSlider: progressSlider,
+		ElapsedLabel:   elapsedLabel,
+		RemainingLabel: remainingLabel,
+		player:         player,
+		settings:       settings,
+	}
+	muteButton.OnTapped = mc.ToggleMute
+	volumeSlider.OnChanged = func(value float64) {
+		// Moving the volume while muted is taken as wanting to hear it again
+		settings.SetVolume(value / 100)
+		mc.applyVolume()
+	}
+	volumeSlider.OnChangeEnded = func(value float64) {
+		// Only written out once the slider is let go rather than on every step of the drag
+		settings.SaveVolume(value / 100)
+	}
+	mc.applyVolume()
+
+	progressSlider.OnChanged = func(value float64) {
+		if mc.updatingProgress.Load() {
+			return
+		}
+		// Show where the song will pick up from while dragging but only seek once the slider is let go
+		mc.scrubbing.Store(true)
+		mc.setTimes(time.Duration(value)*time.Second, time.Duration(progressSlider.Max)*time.Second)
+	}
+	progressSlider.OnChangeEnded = func(value float64) {
+		current := library.CurrentSong()
+		if current == nil {
+			mc.scrubbing.Store(false)
+			return
+		}
+		if err := current.Seek(time.Duration(value) * time.Second); err != nil {
+			log.Println("Could not seek the song: ", err)
+		}
+		mc.scrubbing.Store(false)
 	}
+	go mc.followProgress(library)
+
+	return mc
+}
+
+// Keeps the progress slider and times in step with whatever song is current
+func (mc *MusicControls) followProgress(library *library.Library) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		current := library.CurrentSong()
+		if mc.scrubbing.Load() || current == nil {
+			continue
+		}
+		position := current.Position()
+		duration := current.Duration()
+
+		mc.updatingProgress.Store(true)
+		mc.ProgressSlider.Max = math.Max(1, duration.Seconds())
+		mc.ProgressSlider.SetValue(math.Min(position.Seconds(), mc.ProgressSlider.Max))
+		mc.updatingProgress.Store(false)
+		mc.setTimes(position, duration)
+	}
+}
+
+func (mc *MusicControls) setTimes(position time.Duration, duration time.Duration) {
+	mc.ElapsedLabel.SetText(formatTrackTime(position))
+	if duration > 0 {
+		mc.RemainingLabel.SetText("-" + formatTrackTime(duration-position))
+	} else {
+		mc.RemainingLabel.SetText(formatTrackTime(0))
+	}
+}
+
+// Formats a position in a song as minutes and seconds, such as 3:07
+func formatTrackTime(position time.Duration) string {
+	if position < 0 {
+		position = 0
+	}
+	seconds := int(position / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// Moves the volume up or down by the given amount, clamped between 0 and 1
+func (mc *MusicControls) ChangeVolume(change float64) {
+	mc.VolumeSlider.SetValue(math.Round((mc.settings.VolumeLevel() + change) * 100))
+	mc.settings.SaveVolume(mc.VolumeSlider.Value / 100)
+}
+
+func (mc *MusicControls) ToggleMute() {
+	mc.settings.ToggleMuted()
+	mc.applyVolume()
+}
+
+// Plays at the volume in the settings and updates the mute icon to match
+func (mc *MusicControls) applyVolume() {
+	volume := mc.settings.EffectiveVolume()
+	mc.player.SetVolume(volume)
+	if volume == 0 {
+		mc.MuteButton.SetIcon(theme.VolumeMuteIcon())
+	} else {
+		mc.MuteButton.SetIcon(theme.VolumeUpIcon())
+	}
+}
+
+// Adds the volume keyboard shortcuts to the window: Ctrl+Up and Ctrl+Down to change it and Ctrl+M to mute
+func (mc *MusicControls) AddShortcuts(window fyne.Window) {
+	window.Canvas().AddShortcut(
+		&desktop.CustomShortcut{KeyName: fyne.KeyUp, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { mc.ChangeVolume(pomoapp.VolumeStep) },
+	)
+	window.Canvas().AddShortcut(
+		&desktop.CustomShortcut{KeyName: fyne.KeyDown, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { mc.ChangeVolume(-pomoapp.VolumeStep) },
+	)
+	window.Canvas().AddShortcut(
+		&desktop.CustomShortcut{KeyName: fyne.KeyM, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { mc.ToggleMute() },
+	)
 }
 
 func CreateNewToolbar(
 	app fyne.App,
 	pomodoroTimer *pomodoro.PomodoroTimer,
 	appSettings *pomoapp.Settings,
+	presets *pomodoro.PresetStore,
+	historyStore *history.Store,
+	onSettingsSaved func(),
 ) *widget.Toolbar {
 	return widget.NewToolbar(
 		// TODO(map) What's a good icon to use here? Maybe explore the idea of making my own resource
 		widget.NewToolbarAction(theme.DocumentCreateIcon(), func() {
-			pomodoroCreationWindow := NewPomodoroCreationWindow(app, pomodoroTimer)
+			pomodoroCreationWindow := NewPomodoroCreationWindow(app, pomodoroTimer, presets, nil)
 			pomodoroCreationWindow.Render()
 		}),
+		NewPresetPicker(pomodoroTimer, presets),
+		widget.NewToolbarAction(theme.ListIcon(), func() {
+			presetsWindow := NewPresetsWindow(app, pomodoroTimer, presets)
+			presetsWindow.Render()
+		}),
 		widget.NewToolbarSpacer(),
+		widget.NewToolbarAction(theme.HistoryIcon(), func() {
+			if historyStore == nil {
+				log.Println("History is not being recorded so there are no statistics to show")
+				return
+			}
+			statsWindow := NewStatsWindow(app, historyStore)
+			statsWindow.Render()
+		}),
 		widget.NewToolbarAction(theme.SettingsIcon(), func() {
-			pomodoroSettingsWindow := NewSettingsWindow(app, appSettings)
+			pomodoroSettingsWindow := NewSettingsWindow(app, appSettings, onSettingsSaved)
 			pomodoroSettingsWindow.Render()
 		}),
 		widget.NewToolbarSeparator(),
@@ -0,0 +1,188 @@
+package gui
+
+import (
+	// Internal imports
+	"pomogoro/internal/pomodoro"
+
+	// Gui imports
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Toolbar item holding a drop down of the saved presets. Picking one loads it into the timer.
+type PresetPicker struct {
+	Selector *widget.Select
+}
+
+func NewPresetPicker(pomodoroTimer *pomodoro.PomodoroTimer, presets *pomodoro.PresetStore) *PresetPicker {
+	selector := widget.NewSelect(presets.Names(), func(name string) {
+		// Selecting the preset the timer is already using would otherwise restart it
+		if name == pomodoroTimer.State().PresetName {
+			return
+		}
+		if preset, ok := presets.Find(name); ok {
+			pomodoroTimer.ApplyPreset(preset)
+		}
+	})
+	selector.PlaceHolder = "Saved Pomodoros"
+
+	// Keep the options and selection in step with the presets and the timer
+	presets.Subscribe(func() {
+		selector.SetOptions(presets.Names())
+	})
+	pomodoroTimer.Subscribe(func(state pomodoro.State) {
+		if state.PresetName == "" {
+			selector.ClearSelected()
+		} else if selector.Selected != state.PresetName {
+			selector.SetSelected(state.PresetName)
+		}
+	})
+
+	return &PresetPicker{Selector: selector}
+}
+
+func (picker *PresetPicker) ToolbarObject() fyne.CanvasObject {
+	return container.New(layout.NewGridWrapLayout(fyne.NewSize(200, 36)), picker.Selector)
+}
+
+type PresetsWindow struct {
+	Window     fyne.Window
+	Container  *fyne.Container
+	PresetList *widget.List
+}
+
+// Builds the window to manage the saved presets
+func NewPresetsWindow(
+	app fyne.App,
+	pomodoroTimer *pomodoro.PomodoroTimer,
+	presets *pomodoro.PresetStore,
+) *PresetsWindow {
+	presetsWindow := app.NewWindow("Saved Pomodoros")
+
+	selectedIdx := -1
+	presetList := widget.NewList(
+		func() int {
+			return len(presets.Presets)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			name := presets.Presets[i].Name
+			if name == presets.DefaultPreset {
+				name += " (default)"
+			}
+			o.(*widget.Label).SetText(name)
+		})
+	presetList.OnSelected = func(index int) {
+		selectedIdx = index
+	}
+	presetList.OnUnselected = func(index int) {
+		selectedIdx = -1
+	}
+
+	// Every action works on the currently selected preset so grab it or tell the user to pick one
+	selectedPreset := func() (pomodoro.Preset, bool) {
+		if selectedIdx < 0 || selectedIdx >= len(presets.Presets) {
+			dialog.ShowInformation("No preset selected", "Select a preset from the list first", presetsWindow)
+			return pomodoro.Preset{}, false
+		}
+		return presets.Presets[selectedIdx], true
+	}
+	showErr := func(err error) {
+		if err != nil {
+			dialog.ShowError(err, presetsWindow)
+		}
+	}
+
+	editButton := widget.NewButton("Edit", func() {
+		if preset, ok := selectedPreset(); ok {
+			pomodoroCreationWindow := NewPomodoroCreationWindow(app, pomodoroTimer, presets, &preset)
+			pomodoroCreationWindow.Render()
+		}
+	})
+	renameButton := widget.NewButton("Rename", func() {
+		preset, ok := selectedPreset()
+		if !ok {
+			return
+		}
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(preset.Name)
+		dialog.ShowForm(
+			"Rename Preset",
+			"Rename",
+			"Cancel",
+			[]*widget.FormItem{widget.NewFormItem("Name", nameEntry)},
+			func(confirm bool) {
+				if !confirm {
+					return
+				}
+				if err := presets.Rename(preset.Name, nameEntry.Text); err != nil {
+					showErr(err)
+					return
+				}
+				pomodoroTimer.RenamePreset(preset.Name, nameEntry.Text)
+			},
+			presetsWindow,
+		)
+	})
+	duplicateButton := widget.NewButton("Duplicate", func() {
+		if preset, ok := selectedPreset(); ok {
+			_, err := presets.Duplicate(preset.Name)
+			showErr(err)
+		}
+	})
+	deleteButton := widget.NewButton("Delete", func() {
+		preset, ok := selectedPreset()
+		if !ok {
+			return
+		}
+		dialog.ShowConfirm(
+			"Confirm",
+			"Are you sure you want to delete "+preset.Name+"?",
+			func(confirm bool) {
+				if !confirm {
+					return
+				}
+				showErr(presets.Delete(preset.Name))
+				presetList.UnselectAll()
+			},
+			presetsWindow,
+		)
+	})
+	defaultButton := widget.NewButton("Set Default", func() {
+		if preset, ok := selectedPreset(); ok {
+			showErr(presets.SetDefault(preset.Name))
+		}
+	})
+
+	// The store outlives the window so the list stops following it once the window is closed
+	unsubscribe := presets.Subscribe(presetList.Refresh)
+	presetsWindow.SetOnClosed(unsubscribe)
+
+	listContainer := container.New(layout.NewGridWrapLayout(fyne.NewSize(250, 300)), presetList)
+	buttonContainer := container.New(
+		layout.NewVBoxLayout(),
+		editButton,
+		renameButton,
+		duplicateButton,
+		deleteButton,
+		defaultButton,
+	)
+	content := container.New(layout.NewHBoxLayout(), listContainer, buttonContainer)
+
+	return &PresetsWindow{
+		Window:     presetsWindow,
+		Container:  content,
+		PresetList: presetList,
+	}
+}
+
+func (p *PresetsWindow) Render() {
+	p.Window.SetContent(p.Container)
+	p.Window.Resize(fyne.NewSize(400, 400))
+	p.Window.Show()
+}
@@ -0,0 +1,157 @@
+package gui
+
+import (
+	"math"
+	"sync"
+
+	// Internal imports
+	"pomogoro/internal/library"
+	"pomogoro/internal/song"
+
+	// Gui imports
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Row in the library list that offers to queue its song when right clicked
+type songListItem struct {
+	widget.Label
+
+	song  *song.Song
+	queue *library.Queue
+}
+
+func newSongListItem(queue *library.Queue) *songListItem {
+	item := &songListItem{queue: queue}
+	item.ExtendBaseWidget(item)
+	return item
+}
+
+func (item *songListItem) SetSong(song *song.Song) {
+	item.song = song
+	item.SetText(song.DisplayName())
+}
+
+func (item *songListItem) TappedSecondary(event *fyne.PointEvent) {
+	if item.song == nil {
+		return
+	}
+	queued := item.song
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Play Next", func() {
+			item.queue.PlayNext(queued)
+		}),
+		fyne.NewMenuItem("Add to Queue", func() {
+			item.queue.Enqueue(queued)
+		}),
+	)
+	widget.ShowPopUpMenuAtPosition(menu, fyne.CurrentApp().Driver().CanvasForObject(item), event.AbsolutePosition)
+}
+
+var _ fyne.SecondaryTappable = (*songListItem)(nil)
+
+// Row in the queue that can be dragged up and down to change where its song plays
+type queueListItem struct {
+	widget.Label
+
+	index   int
+	dragged float32
+	queue   *library.Queue
+}
+
+func newQueueListItem(queue *library.Queue) *queueListItem {
+	item := &queueListItem{queue: queue}
+	item.ExtendBaseWidget(item)
+	return item
+}
+
+func (item *queueListItem) Dragged(event *fyne.DragEvent) {
+	item.dragged += event.Dragged.DY
+}
+
+func (item *queueListItem) DragEnd() {
+	// NOTE(map) The row doesn't follow the pointer while dragging, it lands in its new spot once let go
+	rows := int(math.Round(float64(item.dragged / item.MinSize().Height)))
+	item.dragged = 0
+	if rows != 0 {
+		item.queue.Move(item.index, item.index+rows)
+	}
+}
+
+var _ fyne.Draggable = (*queueListItem)(nil)
+
+type QueueView struct {
+	Container   *fyne.Container
+	QueueList   *widget.List
+	ClearButton *widget.Button
+	Queue       *library.Queue
+}
+
+func NewQueueView(labelText string, queue *library.Queue) *QueueView {
+	queueLabel := widget.NewLabel(labelText)
+
+	// The songs are copied out on every change so the list isn't read while the queue is being changed underneath it.
+	// Changes come in on whichever goroutine made them, such as the player's popping the next song, so the copy is
+	// only swapped and read under songsMu.
+	var songsMu sync.Mutex
+	songs := queue.Songs()
+	queueList := widget.NewList(
+		func() int {
+			songsMu.Lock()
+			defer songsMu.Unlock()
+			return len(songs)
+		},
+		func() fyne.CanvasObject {
+			removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			return container.NewBorder(nil, nil, nil, removeButton, newQueueListItem(queue))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*fyne.Container)
+			item := row.Objects[0].(*queueListItem)
+			item.index = i
+			songsMu.Lock()
+			var name string
+			if i < len(songs) {
+				name = songs[i].DisplayName()
+			}
+			songsMu.Unlock()
+			item.SetText(name)
+			row.Objects[1].(*widget.Button).OnTapped = func() {
+				queue.Remove(i)
+			}
+		})
+	clearButton := widget.NewButton("Clear", queue.Clear)
+
+	queueListContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(300, 350)),
+		queueList,
+	)
+	queueHeader := container.New(
+		layout.NewHBoxLayout(),
+		queueLabel,
+		layout.NewSpacer(),
+		clearButton,
+	)
+
+	q := &QueueView{
+		Container: container.New(
+			layout.NewGridWrapLayout(fyne.NewSize(300, 400)),
+			container.New(layout.NewVBoxLayout(), queueHeader, queueListContainer),
+		),
+		QueueList:   queueList,
+		ClearButton: clearButton,
+		Queue:       queue,
+	}
+	queue.Subscribe(func() {
+		updated := queue.Songs()
+		songsMu.Lock()
+		songs = updated
+		songsMu.Unlock()
+		queueList.Refresh()
+	})
+
+	return q
+}
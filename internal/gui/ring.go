@@ -0,0 +1,164 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/pomodoro"
+
+	// Gui imports
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	ringThickness     = 0.12                   // Width of the ring as a fraction of its radius
+	ringFrameInterval = 250 * time.Millisecond // How often the ring is redrawn while the timer is running
+)
+
+var (
+	ringTrackColor     = color.RGBA{60, 60, 60, 255}
+	ringFocusColor     = color.RGBA{229, 83, 61, 255}
+	ringRelaxColor     = color.RGBA{76, 175, 80, 255}
+	ringLongBreakColor = color.RGBA{66, 133, 244, 255}
+)
+
+// Circle behind the timer with a ring around the edge that fills clockwise as the current phase runs. The fill is
+// worked out from when the timer last published so it moves along a few times a second instead of jumping once a
+// second. It is only redrawn on a timer of its own while the pomodoro timer is running.
+type ProgressRing struct {
+	widget.BaseWidget
+
+	mu          sync.Mutex
+	phase       pomodoro.Phase
+	running     bool
+	elapsed     time.Duration // Time into the phase as of updatedAt
+	phaseLength time.Duration
+	updatedAt   time.Time
+
+	stopFrames chan struct{} // Closed to stop redrawing once the timer stops, nil while it isn't running
+
+	raster *canvas.Raster
+}
+
+func NewProgressRing() *ProgressRing {
+	ring := &ProgressRing{}
+	ring.raster = canvas.NewRaster(ring.draw)
+	ring.ExtendBaseWidget(ring)
+	return ring
+}
+
+func (ring *ProgressRing) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(ring.raster)
+}
+
+// Catches the ring up with the latest state published by the timer
+func (ring *ProgressRing) SetState(state pomodoro.State) {
+	ring.mu.Lock()
+	ring.phase = state.Phase
+	ring.running = state.IsRunning
+	ring.phaseLength = state.PhaseLength
+	ring.elapsed = state.PhaseLength - state.Remaining
+	ring.updatedAt = time.Now()
+
+	// Only redraws between updates while the timer is running, otherwise the ring is already showing the right thing
+	if ring.running && ring.stopFrames == nil {
+		ring.stopFrames = make(chan struct{})
+		go ring.redraw(ring.stopFrames)
+	} else if !ring.running && ring.stopFrames != nil {
+		close(ring.stopFrames)
+		ring.stopFrames = nil
+	}
+	ring.mu.Unlock()
+
+	ring.raster.Refresh()
+}
+
+func (ring *ProgressRing) redraw(stop chan struct{}) {
+	ticker := time.NewTicker(ringFrameInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ring.raster.Refresh()
+		}
+	}
+}
+
+// Fraction of the current phase that has passed, between 0 and 1
+func (ring *ProgressRing) progress() float64 {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	if ring.phase == pomodoro.CompletePhase {
+		return 1
+	}
+	if ring.phaseLength <= 0 {
+		return 0
+	}
+
+	elapsed := ring.elapsed
+	if ring.running {
+		elapsed += time.Since(ring.updatedAt)
+	}
+	return math.Max(0, math.Min(1, float64(elapsed)/float64(ring.phaseLength)))
+}
+
+func (ring *ProgressRing) fillColor() color.RGBA {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	switch ring.phase {
+	case pomodoro.RelaxPhase, pomodoro.CompletePhase:
+		return ringRelaxColor
+	case pomodoro.LongBreakPhase:
+		return ringLongBreakColor
+	default:
+		return ringFocusColor
+	}
+}
+
+func (ring *ProgressRing) draw(w int, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	progress := ring.progress()
+	fill := ring.fillColor()
+	// Matches the window so the timer text drawn on top stays readable in both light and dark themes
+	innerColor := color.RGBAModel.Convert(theme.BackgroundColor()).(color.RGBA)
+
+	centerX, centerY := float64(w)/2, float64(h)/2
+	outer := math.Min(centerX, centerY)
+	inner := outer * (1 - ringThickness)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := float64(x)+0.5-centerX, float64(y)+0.5-centerY
+			distance := math.Hypot(dx, dy)
+			if distance > outer {
+				continue
+			}
+			if distance < inner {
+				img.SetRGBA(x, y, innerColor)
+				continue
+			}
+
+			// Angle measured clockwise from twelve o'clock as a fraction of a full turn
+			angle := math.Atan2(dx, -dy) / (2 * math.Pi)
+			if angle < 0 {
+				angle += 1
+			}
+			if angle <= progress {
+				img.SetRGBA(x, y, fill)
+			} else {
+				img.SetRGBA(x, y, ringTrackColor)
+			}
+		}
+	}
+	return img
+}
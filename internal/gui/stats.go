@@ -0,0 +1,139 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/history"
+
+	// Gui imports
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	barWidth     = 50
+	barMaxHeight = 120
+)
+
+type StatsWindow struct {
+	Window    fyne.Window
+	Container *fyne.Container
+
+	loadErr error // Problem reading the history, shown once the window is open
+}
+
+// Builds the productivity statistics window from everything recorded in the history store
+func NewStatsWindow(app fyne.App, store *history.Store) *StatsWindow {
+	statsWindow := app.NewWindow("Statistics")
+
+	// Whatever could be read is still shown if loading fails part way through
+	entries, err := store.Load()
+	stats := history.ComputeStats(entries, time.Now())
+
+	// Focus time bar charts
+	dailyLabels := make([]string, len(stats.DailyFocus))
+	dailyMinutes := make([]int, len(stats.DailyFocus))
+	for i, total := range stats.DailyFocus {
+		dailyLabels[i] = total.Start.Format("Mon")
+		dailyMinutes[i] = total.FocusSeconds / 60
+	}
+	weeklyLabels := make([]string, len(stats.WeeklyFocus))
+	weeklyMinutes := make([]int, len(stats.WeeklyFocus))
+	for i, total := range stats.WeeklyFocus {
+		weeklyLabels[i] = total.Start.Format("Jan 2")
+		weeklyMinutes[i] = total.FocusSeconds / 60
+	}
+	dailyChart := container.New(
+		layout.NewVBoxLayout(),
+		widget.NewLabel("Focus minutes per day"),
+		newBarChart(dailyLabels, dailyMinutes),
+	)
+	weeklyChart := container.New(
+		layout.NewVBoxLayout(),
+		widget.NewLabel("Focus minutes per week"),
+		newBarChart(weeklyLabels, weeklyMinutes),
+	)
+
+	// Session summary
+	summary := container.New(
+		layout.NewVBoxLayout(),
+		widget.NewLabel(fmt.Sprintf("Completed sessions: %d", stats.CompletedSessions)),
+		widget.NewLabel(fmt.Sprintf("Abandoned sessions: %d", stats.AbandonedSessions)),
+		widget.NewLabel(fmt.Sprintf("Average pauses per session: %.1f", stats.AveragePauses)),
+		widget.NewLabel(fmt.Sprintf(
+			"Current streak: %d days (longest %d days)",
+			stats.CurrentStreak,
+			stats.LongestStreak,
+		)),
+	)
+
+	// Most played focus tracks
+	tracks := container.New(layout.NewVBoxLayout(), widget.NewLabel("Most played focus tracks"))
+	if len(stats.TopFocusTracks) == 0 {
+		tracks.Add(widget.NewLabel("No tracks played during focus yet"))
+	}
+	for i, track := range stats.TopFocusTracks {
+		tracks.Add(widget.NewLabel(fmt.Sprintf("%d. %s (%d plays)", i+1, track.Song, track.Plays)))
+	}
+
+	content := container.New(
+		layout.NewVBoxLayout(),
+		container.New(layout.NewHBoxLayout(), dailyChart, weeklyChart),
+		container.New(layout.NewHBoxLayout(), summary, tracks),
+	)
+
+	return &StatsWindow{
+		Window:    statsWindow,
+		Container: content,
+		loadErr:   err,
+	}
+}
+
+func (s *StatsWindow) Render() {
+	s.Window.SetContent(s.Container)
+	s.Window.Resize(fyne.NewSize(700, 500))
+	s.Window.Show()
+	if s.loadErr != nil {
+		dialog.ShowError(s.loadErr, s.Window)
+	}
+}
+
+// Draws a simple vertical bar chart with the value above each bar and its label underneath
+func newBarChart(labels []string, values []int) *fyne.Container {
+	maxValue := 0
+	for _, value := range values {
+		if value > maxValue {
+			maxValue = value
+		}
+	}
+
+	bars := container.New(layout.NewHBoxLayout())
+	for i, value := range values {
+		height := float32(0)
+		if maxValue > 0 {
+			height = float32(value) / float32(maxValue) * barMaxHeight
+		}
+		bar := canvas.NewRectangle(theme.PrimaryColor())
+		bar.SetMinSize(fyne.NewSize(barWidth, height))
+
+		// Spacer pushes the bar down so all the bars line up along the bottom
+		barArea := container.New(
+			layout.NewGridWrapLayout(fyne.NewSize(barWidth, barMaxHeight)),
+			container.New(layout.NewVBoxLayout(), layout.NewSpacer(), bar),
+		)
+		valueText := canvas.NewText(fmt.Sprintf("%d", value), theme.ForegroundColor())
+		valueText.Alignment = fyne.TextAlignCenter
+		labelText := canvas.NewText(labels[i], theme.ForegroundColor())
+		labelText.Alignment = fyne.TextAlignCenter
+
+		bars.Add(container.New(layout.NewVBoxLayout(), valueText, barArea, labelText))
+	}
+	return bars
+}
@@ -0,0 +1,191 @@
+package gui
+
+import (
+	"fmt"
+
+	// Internal imports
+	"pomogoro/internal/pomoapp"
+	"pomogoro/internal/pomodoro"
+
+	// Gui imports
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Fyne frontend for the pomodoro timer. It subscribes to the timer and re-renders whenever the state changes.
+//
+// The timer publishes from its own goroutine, so the text is kept in bindings rather than written straight into the
+// widgets. Fyne picks the binding changes up safely on its side.
+type PomodoroTimerCanvas struct {
+	// Circle container to hold all the data and controls
+	CircleContainer *fyne.Container
+	ProgressRing    *ProgressRing
+
+	// Text to display mode
+	ModeBinding            binding.String
+	ModeText               *widget.Label
+	ModeTextInnerContainer *fyne.Container
+	ModeTextContainer      *fyne.Container
+
+	// Timer related components
+	TimerBinding            binding.String
+	TimerText               *widget.Label
+	TimerTextInnerContainer *fyne.Container
+	TimerTextContainer      *fyne.Container
+
+	// Iterations related components
+	IterationBinding            binding.String
+	IterationText               *widget.Label
+	IterationTextInnerContainer *fyne.Container
+	IterationTextContainer      *fyne.Container
+
+	// Controls
+	PlayButton           *widget.Button
+	PlayButtonContainer  *fyne.Container
+	ResetButton          *widget.Button
+	ResetButtonContainer *fyne.Container
+
+	// Parent containers
+	TextContainer    *fyne.Container
+	ControlContainer *fyne.Container
+
+	// Wrapper
+	TextPlusControlContainer *fyne.Container
+
+	// Top level container
+	TopLevelContainer *fyne.Container
+}
+
+func NewPomodoroTimerCanvas(pt *pomodoro.PomodoroTimer, settings *pomoapp.Settings) *PomodoroTimerCanvas {
+	progressRing := NewProgressRing()
+	circleContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(300, 300)),
+		progressRing,
+	)
+	modeBinding := binding.NewString()
+	modeBinding.Set("Focus")
+	modeText := widget.NewLabelWithData(modeBinding)
+	modeText.Alignment = fyne.TextAlignCenter
+	modeTextInnerContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
+		modeText,
+	)
+	modeTextContainer := container.New(layout.NewCenterLayout(), modeTextInnerContainer)
+	timerBinding := binding.NewString()
+	timerBinding.Set("No Timer Created")
+	timerText := widget.NewLabelWithData(timerBinding)
+	timerText.Alignment = fyne.TextAlignCenter
+	timerTextInnerContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
+		timerText,
+	)
+	timerTextContainer := container.New(layout.NewCenterLayout(), timerTextInnerContainer)
+	iterationBinding := binding.NewString()
+	iterationText := widget.NewLabelWithData(iterationBinding)
+	iterationText.Alignment = fyne.TextAlignCenter
+	iterationTextInnerContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
+		iterationText,
+	)
+	iterationTextContainer := container.New(layout.NewCenterLayout(), iterationTextInnerContainer)
+	playButton := widget.NewButton("Play", func() {
+		// Checking and flipping happen together inside the timer so a double click can't start it twice
+		pt.ToggleTimer()
+		if settings.LinkPlayers {
+			// TODO(map) Re-add code to have the players linked
+		}
+	})
+	playButtonContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
+		playButton,
+	)
+	resetButton := widget.NewButton("Restart", pt.RestartTimer)
+	resetButtonContainer := container.New(
+		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
+		resetButton,
+	)
+	textContainer := container.New(
+		layout.NewVBoxLayout(),
+		modeTextContainer,
+		timerTextContainer,
+		iterationTextContainer,
+	)
+	controlContainer := container.New(
+		layout.NewHBoxLayout(),
+		playButtonContainer,
+		resetButtonContainer,
+	)
+
+	textPlusControlContainer := container.NewVBox(textContainer, controlContainer)
+	topLevelContainer := container.New(
+		layout.NewCenterLayout(),
+		circleContainer,
+		textPlusControlContainer,
+	)
+
+	c := &PomodoroTimerCanvas{
+		CircleContainer:             circleContainer,
+		ProgressRing:                progressRing,
+		ModeBinding:                 modeBinding,
+		ModeText:                    modeText,
+		ModeTextInnerContainer:      modeTextInnerContainer,
+		ModeTextContainer:           modeTextContainer,
+		TimerBinding:                timerBinding,
+		TimerText:                   timerText,
+		TimerTextInnerContainer:     timerTextInnerContainer,
+		TimerTextContainer:          timerTextContainer,
+		IterationBinding:            iterationBinding,
+		IterationText:               iterationText,
+		IterationTextInnerContainer: iterationTextInnerContainer,
+		IterationTextContainer:      iterationTextContainer,
+		PlayButton:                  playButton,
+		PlayButtonContainer:         playButtonContainer,
+		ResetButton:                 resetButton,
+		ResetButtonContainer:        resetButtonContainer,
+		TextContainer:               textContainer,
+		ControlContainer:            controlContainer,
+		TextPlusControlContainer:    textPlusControlContainer,
+		TopLevelContainer:           topLevelContainer,
+	}
+
+	// Only start rendering the timer once there is one to render, otherwise leave the "No Timer Created" text
+	pt.Subscribe(func(state pomodoro.State) {
+		if state.Iterations == 0 {
+			return
+		}
+		c.Update(state)
+	})
+
+	return c
+}
+
+// Renders the latest state published by the timer
+func (c *PomodoroTimerCanvas) Update(state pomodoro.State) {
+	c.ProgressRing.SetState(state)
+	c.UpdateModeText(state)
+	c.UpdateTimerText(state)
+	c.UpdateIterationText(state)
+}
+
+func (c *PomodoroTimerCanvas) UpdateModeText(state pomodoro.State) {
+	c.ModeBinding.Set(state.Phase.String())
+}
+
+func (c *PomodoroTimerCanvas) UpdateTimerText(state pomodoro.State) {
+	c.TimerBinding.Set(fmt.Sprintf(
+		"%d min %d sec",
+		int(state.CurrentTimer/60),
+		int(state.CurrentTimer%60),
+	))
+}
+
+func (c *PomodoroTimerCanvas) UpdateIterationText(state pomodoro.State) {
+	c.IterationBinding.Set(fmt.Sprintf(
+		"Completed %d of %d Iterations",
+		state.IterationCount,
+		state.Iterations,
+	))
+}
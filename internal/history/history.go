@@ -0,0 +1,158 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/messages"
+	"pomogoro/internal/pomodoro"
+)
+
+// A single line of the history file
+type Entry struct {
+	Time           time.Time
+	Event          string
+	Phase          string
+	CurrentTimer   int // Time that was left in the phase in seconds
+	IterationCount int
+	Iterations     int
+	PresetName     string   `json:",omitempty"`
+	Songs          []string `json:",omitempty"` // Songs that played during the phase, only set when a phase finishes
+}
+
+// Append only log of everything the timer did, stored as one JSON object per line so a crash can at worst lose the
+// line being written
+type Store struct {
+	FilePath string
+
+	mu sync.Mutex
+}
+
+func NewStore(filePath string) *Store {
+	return &Store{FilePath: filePath}
+}
+
+func (store *Store) Append(entry Entry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshalling history entry: %w", err)
+	}
+
+	f, err := os.OpenFile(store.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	return nil
+}
+
+// Reads back every entry in the order they were written. Lines that can't be parsed are skipped so one bad write
+// doesn't lose the rest of the history.
+func (store *Store) Load() ([]Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entries := []Entry{}
+	f, err := os.Open(store.FilePath)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("opening history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("Skipping unreadable history line: %v", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("reading history: %w", err)
+	}
+	return entries, nil
+}
+
+// Listens to the timer and the player and writes what happened to the store
+type Recorder struct {
+	store *Store
+
+	mu          sync.Mutex
+	currentSong string   // The song playing right now, empty if nothing is
+	phaseSongs  []string // Every song that has played since the current phase started
+}
+
+func NewRecorder(store *Store) *Recorder {
+	return &Recorder{store: store}
+}
+
+// Records a timer event, meant to be passed to PomodoroTimer.SubscribeEvents
+func (recorder *Recorder) Record(event pomodoro.Event) {
+	recorder.mu.Lock()
+	entry := Entry{
+		Time:           event.Time,
+		Event:          event.Kind.String(),
+		Phase:          event.Phase.String(),
+		CurrentTimer:   event.CurrentTimer,
+		IterationCount: event.IterationCount,
+		Iterations:     event.Iterations,
+		PresetName:     event.PresetName,
+	}
+	switch event.Kind {
+	case pomodoro.PhaseStarted:
+		// Whatever is already playing counts towards the new phase
+		recorder.phaseSongs = nil
+		if recorder.currentSong != "" {
+			recorder.phaseSongs = append(recorder.phaseSongs, recorder.currentSong)
+		}
+	case pomodoro.PhaseEnded, pomodoro.PhaseSkipped, pomodoro.TimerReset, pomodoro.SessionCompleted:
+		entry.Songs = recorder.phaseSongs
+		recorder.phaseSongs = nil
+	}
+	recorder.mu.Unlock()
+
+	if err := recorder.store.Append(entry); err != nil {
+		log.Print("Failure in recording history: ", err)
+	}
+}
+
+// Follows the songs being played, meant to be subscribed to the player's event bus
+func (recorder *Recorder) Observe(event messages.Event) {
+	switch event.Kind {
+	case messages.TrackStarted:
+		recorder.SongStarted(event.SongName)
+	case messages.TrackFinished, messages.TrackSkipped, messages.TrackStopped, messages.TrackError:
+		recorder.SongStopped()
+	}
+}
+
+func (recorder *Recorder) SongStarted(name string) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.currentSong = name
+	recorder.phaseSongs = append(recorder.phaseSongs, name)
+}
+
+func (recorder *Recorder) SongStopped() {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.currentSong = ""
+}
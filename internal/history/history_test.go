@@ -0,0 +1,108 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/messages"
+	"pomogoro/internal/pomodoro"
+)
+
+func TestRecorderRoundTripsThroughTheStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	recorder := NewRecorder(store)
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	event := func(kind pomodoro.EventKind, phase pomodoro.Phase, minutes int, currentTimer int) pomodoro.Event {
+		return pomodoro.Event{
+			Kind:         kind,
+			Time:         start.Add(time.Duration(minutes) * time.Minute),
+			Phase:        phase,
+			CurrentTimer: currentTimer,
+			Iterations:   4,
+			PresetName:   "Classic",
+		}
+	}
+
+	// A song already playing when focus starts counts towards it as well as the ones started during it
+	recorder.Observe(messages.Event{Kind: messages.TrackStarted, SongName: "intro.mp3"})
+	recorder.Record(event(pomodoro.PhaseStarted, pomodoro.FocusPhase, 0, 1500))
+	recorder.Observe(messages.Event{Kind: messages.TrackFinished, SongName: "intro.mp3"})
+	recorder.Observe(messages.Event{Kind: messages.TrackStarted, SongName: "a.mp3"})
+	recorder.Record(event(pomodoro.TimerPaused, pomodoro.FocusPhase, 10, 900))
+	recorder.Record(event(pomodoro.TimerResumed, pomodoro.FocusPhase, 12, 900))
+	recorder.Observe(messages.Event{Kind: messages.TrackSkipped, SongName: "a.mp3"})
+	recorder.Observe(messages.Event{Kind: messages.TrackStarted, SongName: "b.mp3"})
+	recorder.Record(event(pomodoro.PhaseEnded, pomodoro.FocusPhase, 27, 0))
+
+	// Nothing is playing by the time relax starts, so it has no songs
+	recorder.Observe(messages.Event{Kind: messages.TrackStopped, SongName: "b.mp3"})
+	recorder.Record(event(pomodoro.PhaseStarted, pomodoro.RelaxPhase, 27, 300))
+	recorder.Record(event(pomodoro.PhaseSkipped, pomodoro.RelaxPhase, 28, 240))
+
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := func(kind pomodoro.EventKind, phase pomodoro.Phase, minutes int, currentTimer int, songs ...string) Entry {
+		return Entry{
+			Time:         start.Add(time.Duration(minutes) * time.Minute),
+			Event:        kind.String(),
+			Phase:        phase.String(),
+			CurrentTimer: currentTimer,
+			Iterations:   4,
+			PresetName:   "Classic",
+			Songs:        songs,
+		}
+	}
+	want := []Entry{
+		entry(pomodoro.PhaseStarted, pomodoro.FocusPhase, 0, 1500),
+		entry(pomodoro.TimerPaused, pomodoro.FocusPhase, 10, 900),
+		entry(pomodoro.TimerResumed, pomodoro.FocusPhase, 12, 900),
+		entry(pomodoro.PhaseEnded, pomodoro.FocusPhase, 27, 0, "intro.mp3", "a.mp3", "b.mp3"),
+		entry(pomodoro.PhaseStarted, pomodoro.RelaxPhase, 27, 300),
+		entry(pomodoro.PhaseSkipped, pomodoro.RelaxPhase, 28, 240),
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("read back\n%+v\nwant\n%+v", entries, want)
+	}
+}
+
+func TestStoreSkipsUnreadableLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := NewStore(path)
+	if err := store.Append(Entry{Event: "phase_started", Phase: "Focus"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A write cut short by a crash leaves half a line behind
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"Event":"phase_en` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if err := store.Append(Entry{Event: "reset", Phase: "Focus"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Event != "phase_started" || entries[1].Event != "reset" {
+		t.Fatalf("read back %+v, want the entries either side of the broken line", entries)
+	}
+}
+
+func TestStoreLoadsNothingBeforeTheFirstWrite(t *testing.T) {
+	entries, err := NewStore(filepath.Join(t.TempDir(), "history.jsonl")).Load()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Load() = %v, %v, want no entries", entries, err)
+	}
+}
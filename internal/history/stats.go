@@ -0,0 +1,203 @@
+package history
+
+import (
+	"sort"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/pomodoro"
+)
+
+const (
+	statsDays      = 7 // Number of days shown in the daily focus totals
+	statsWeeks     = 4 // Number of weeks shown in the weekly focus totals
+	statsTopTracks = 5 // Number of most played focus tracks to keep
+)
+
+// Focus time spent in a single day or week, Start being the first day of it
+type FocusTotal struct {
+	Start        time.Time
+	FocusSeconds int
+}
+
+type TrackPlays struct {
+	Song  string
+	Plays int
+}
+
+// Summary of the history used by the statistics window
+type Stats struct {
+	DailyFocus  []FocusTotal // The last statsDays days, oldest first
+	WeeklyFocus []FocusTotal // The last statsWeeks weeks starting on Monday, oldest first
+
+	CompletedSessions int
+	AbandonedSessions int
+	AveragePauses     float64 // Average number of pauses in a finished or abandoned session
+
+	CurrentStreak int // Consecutive days up to today with at least one finished focus period
+	LongestStreak int
+
+	TopFocusTracks []TrackPlays // Songs that played the most during focus periods, most played first
+}
+
+// Works out the statistics for the entries as of now. Days are split on midnight in now's location.
+func ComputeStats(entries []Entry, now time.Time) Stats {
+	focusByDay := map[time.Time]int{}
+	focusDays := map[time.Time]bool{}
+	trackPlays := map[string]int{}
+
+	var stats Stats
+	var focusRunningSince *time.Time
+	focusLeft := 0 // Seconds that were left in the focus phase when it started running, no span can be longer
+	sessionOpen := false
+	sessionPauses := 0
+	totalPauses := 0
+
+	// Focus time only accrues while the timer is actually running in a focus phase
+	startFocus := func(entry Entry) {
+		at := entry.Time
+		focusRunningSince = &at
+		focusLeft = entry.CurrentTimer
+	}
+	stopFocus := func(at time.Time) {
+		if focusRunningSince != nil {
+			day := startOfDay(*focusRunningSince, now.Location())
+			seconds := int(at.Sub(*focusRunningSince) / time.Second)
+			if seconds > focusLeft {
+				seconds = focusLeft
+			}
+			focusByDay[day] += seconds
+			focusRunningSince = nil
+		}
+	}
+	closeSession := func(completed bool) {
+		if !sessionOpen {
+			return
+		}
+		if completed {
+			stats.CompletedSessions += 1
+		} else {
+			stats.AbandonedSessions += 1
+		}
+		totalPauses += sessionPauses
+		sessionOpen = false
+	}
+
+	for _, entry := range entries {
+		isFocus := entry.Phase == pomodoro.FocusPhase.String()
+		switch entry.Event {
+		case pomodoro.PhaseStarted.String():
+			// Focus still running here means the app was closed before it ended. There is no telling how much of the
+			// gap was spent focusing so none of it is counted.
+			focusRunningSince = nil
+			if isFocus && entry.IterationCount == 0 {
+				// A new session starting while another is still open means the app was closed part way through
+				closeSession(false)
+				sessionOpen = true
+				sessionPauses = 0
+			}
+			if isFocus {
+				startFocus(entry)
+			}
+		case pomodoro.TimerResumed.String():
+			if isFocus {
+				startFocus(entry)
+			}
+		case pomodoro.TimerPaused.String():
+			stopFocus(entry.Time)
+			sessionPauses += 1
+		case pomodoro.PhaseEnded.String(), pomodoro.PhaseSkipped.String():
+			stopFocus(entry.Time)
+			if isFocus && entry.Event == pomodoro.PhaseEnded.String() {
+				focusDays[startOfDay(entry.Time, now.Location())] = true
+			}
+		case pomodoro.TimerReset.String():
+			stopFocus(entry.Time)
+			closeSession(false)
+		case pomodoro.SessionCompleted.String():
+			stopFocus(entry.Time)
+			closeSession(true)
+		}
+
+		if isFocus {
+			for _, song := range entry.Songs {
+				trackPlays[song] += 1
+			}
+		}
+	}
+
+	if finished := stats.CompletedSessions + stats.AbandonedSessions; finished > 0 {
+		stats.AveragePauses = float64(totalPauses) / float64(finished)
+	}
+
+	// Daily and weekly totals counting back from today
+	today := startOfDay(now, now.Location())
+	for i := statsDays - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		stats.DailyFocus = append(stats.DailyFocus, FocusTotal{Start: day, FocusSeconds: focusByDay[day]})
+	}
+	thisWeek := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	for i := statsWeeks - 1; i >= 0; i-- {
+		week := FocusTotal{Start: thisWeek.AddDate(0, 0, -7*i)}
+		for day := 0; day < 7; day++ {
+			week.FocusSeconds += focusByDay[week.Start.AddDate(0, 0, day)]
+		}
+		stats.WeeklyFocus = append(stats.WeeklyFocus, week)
+	}
+
+	stats.CurrentStreak, stats.LongestStreak = streaks(focusDays, today)
+	stats.TopFocusTracks = topTracks(trackPlays)
+	return stats
+}
+
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// Returns the streak running up to today and the longest streak ever. A streak that ended yesterday still counts as
+// current since today isn't over yet.
+func streaks(focusDays map[time.Time]bool, today time.Time) (int, int) {
+	current := 0
+	day := today
+	if !focusDays[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for focusDays[day] {
+		current += 1
+		day = day.AddDate(0, 0, -1)
+	}
+
+	longest := 0
+	for day := range focusDays {
+		// Only count forward from the first day of each streak
+		if focusDays[day.AddDate(0, 0, -1)] {
+			continue
+		}
+		length := 0
+		for next := day; focusDays[next]; next = next.AddDate(0, 0, 1) {
+			length += 1
+		}
+		if length > longest {
+			longest = length
+		}
+	}
+	return current, longest
+}
+
+func topTracks(trackPlays map[string]int) []TrackPlays {
+	tracks := make([]TrackPlays, 0, len(trackPlays))
+	for song, plays := range trackPlays {
+		tracks = append(tracks, TrackPlays{Song: song, Plays: plays})
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		if tracks[i].Plays != tracks[j].Plays {
+			return tracks[i].Plays > tracks[j].Plays
+		}
+		return tracks[i].Song < tracks[j].Song
+	})
+	if len(tracks) > statsTopTracks {
+		tracks = tracks[:statsTopTracks]
+	}
+	return tracks
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/pomodoro"
+)
+
+func focusEntry(event pomodoro.EventKind, at time.Time, currentTimer int) Entry {
+	return Entry{
+		Time:         at,
+		Event:        event.String(),
+		Phase:        pomodoro.FocusPhase.String(),
+		CurrentTimer: currentTimer,
+		Iterations:   4,
+	}
+}
+
+func TestComputeStatsFocusTime(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	minutes := func(n int) time.Time { return start.Add(time.Duration(n) * time.Minute) }
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		now     time.Time
+		want    int // Focus seconds counted on the first day
+	}{
+		{
+			name: "finished focus",
+			entries: []Entry{
+				focusEntry(pomodoro.PhaseStarted, start, 1500),
+				focusEntry(pomodoro.PhaseEnded, minutes(25), 0),
+			},
+			now:  minutes(30),
+			want: 1500,
+		},
+		{
+			name: "pauses are left out",
+			entries: []Entry{
+				focusEntry(pomodoro.PhaseStarted, start, 1500),
+				focusEntry(pomodoro.TimerPaused, minutes(10), 900),
+				focusEntry(pomodoro.TimerResumed, minutes(40), 900),
+				focusEntry(pomodoro.PhaseEnded, minutes(55), 0),
+			},
+			now:  minutes(60),
+			want: 1500,
+		},
+		{
+			name: "closed mid focus and started again the next day",
+			entries: []Entry{
+				focusEntry(pomodoro.PhaseStarted, start, 1500),
+				focusEntry(pomodoro.PhaseStarted, start.Add(26*time.Hour), 1500),
+			},
+			now:  start.Add(27 * time.Hour),
+			want: 0,
+		},
+		{
+			name: "span capped at the time that was left",
+			entries: []Entry{
+				focusEntry(pomodoro.PhaseStarted, start, 1500),
+				focusEntry(pomodoro.TimerPaused, minutes(10), 900),
+				focusEntry(pomodoro.TimerResumed, minutes(20), 900),
+				// Ended long after the phase could have run out
+				focusEntry(pomodoro.TimerReset, minutes(120), 0),
+			},
+			now:  minutes(130),
+			want: 600 + 900,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stats := ComputeStats(test.entries, test.now)
+			day := startOfDay(start, test.now.Location())
+			got := -1
+			for _, total := range stats.DailyFocus {
+				if total.Start.Equal(day) {
+					got = total.FocusSeconds
+				}
+			}
+			if got != test.want {
+				t.Fatalf("got %d focus seconds on the first day, want %d", got, test.want)
+			}
+		})
+	}
+}
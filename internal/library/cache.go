@@ -0,0 +1,167 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+	"pomogoro/internal/song"
+
+	// ID3
+	"github.com/bogem/id3v2"
+)
+
+const cacheVersion = 2 // Bumped whenever what is kept changes so older caches are read from scratch again
+
+// What is known about a file in the library as of when it was last read. The entry is only trusted while the size and
+// modification time of the file still match.
+type CacheEntry struct {
+	Size    int64
+	ModTime time.Time
+	Format  string
+
+	song.Details
+	Duration time.Duration
+}
+
+// Details of every file in the library kept on disk between launches, so loading the library only has to read the
+// files that are new or have changed
+type MetadataCache struct {
+	FilePath string                `json:"-"` // Nothing is written out when empty
+	Version  int                   // Version of the cache the entries were written by
+	Entries  map[string]CacheEntry // Keyed by the path of the file
+
+	mu    sync.Mutex
+	dirty bool // Entries have changed since they were last saved
+}
+
+func NewMetadataCache(filePath string) *MetadataCache {
+	return &MetadataCache{
+		FilePath: filePath,
+		Version:  cacheVersion,
+		Entries:  map[string]CacheEntry{},
+	}
+}
+
+func (cache *MetadataCache) Load() error {
+	if cache.FilePath == "" {
+		return nil
+	}
+	cacheFile, err := os.ReadFile(cache.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		// The library hasn't been read before
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading library cache: %w", err)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	loaded := NewMetadataCache(cache.FilePath)
+	if err := json.Unmarshal(cacheFile, loaded); err != nil {
+		return fmt.Errorf("unmarshalling library cache: %w", err)
+	}
+	if loaded.Version != cacheVersion || loaded.Entries == nil {
+		// Written by a different version so everything gets read again
+		return nil
+	}
+	cache.Entries = loaded.Entries
+	cache.dirty = false
+	return nil
+}
+
+// Writes the entries out if they have changed since they were loaded or last saved
+func (cache *MetadataCache) Save() error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.FilePath == "" || !cache.dirty {
+		return nil
+	}
+
+	file, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("marshalling library cache: %w", err)
+	}
+	// Written alongside and moved over the old cache so quitting part way through can't leave half of one behind
+	tempPath := cache.FilePath + ".tmp"
+	if err := os.WriteFile(tempPath, file, 0644); err != nil {
+		return fmt.Errorf("writing library cache: %w", err)
+	}
+	if err := os.Rename(tempPath, cache.FilePath); err != nil {
+		return fmt.Errorf("writing library cache: %w", err)
+	}
+	cache.dirty = false
+	return nil
+}
+
+// Forgets every entry so each file is read again the next time the library is scanned
+func (cache *MetadataCache) Clear() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.Entries = map[string]CacheEntry{}
+	cache.dirty = true
+}
+
+// The entry for the file as long as the file hasn't changed since it was made
+func (cache *MetadataCache) lookup(file scannedFile) (CacheEntry, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry, ok := cache.Entries[file.Path]
+	if !ok || entry.Size != file.Size || !entry.ModTime.Equal(file.ModTime) {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (cache *MetadataCache) store(path string, entry CacheEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.Entries[path] = entry
+	cache.dirty = true
+}
+
+// Drops the entries for files that are no longer in the library
+func (cache *MetadataCache) prune(files []scannedFile) {
+	keep := map[string]bool{}
+	for _, file := range files {
+		keep[file.Path] = true
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for path := range cache.Entries {
+		if !keep[path] {
+			delete(cache.Entries, path)
+			cache.dirty = true
+		}
+	}
+}
+
+// Reads the tags and length of the file, leaving out whatever can't be read so one bad tag doesn't lose the song
+func readEntry(file scannedFile) CacheEntry {
+	entry := CacheEntry{
+		Size:    file.Size,
+		ModTime: file.ModTime,
+		Format:  file.Format,
+	}
+
+	if tag, err := id3v2.Open(file.Path, id3v2.Options{Parse: true}); err == nil {
+		entry.Details = song.ReadDetails(tag)
+		tag.Close()
+	}
+	if stream, err := audio.OpenFile(file.Path); err == nil {
+		entry.Duration = stream.Duration()
+		stream.Close()
+	}
+	return entry
+}
+
+// Fills in the song from the entry. The tag only lives in memory, saving changes to it means opening the file's own.
+func (entry CacheEntry) apply(librarySong *song.Song) {
+	librarySong.Describe(entry.Details, entry.Duration)
+}
@@ -0,0 +1,112 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/song"
+)
+
+func TestCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	modTime := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	file := scannedFile{Path: "/music/a.mp3", Size: 1234, ModTime: modTime, Format: "MP3"}
+	entry := CacheEntry{
+		Size:     file.Size,
+		ModTime:  file.ModTime,
+		Format:   file.Format,
+		Details:  song.Details{Title: "Song A", Artist: "Artist", Album: "Album", Year: "1997", Track: "3"},
+		Duration: 3*time.Minute + 7*time.Second,
+	}
+	cache := NewMetadataCache(path)
+	cache.store(file.Path, entry)
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewMetadataCache(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := loaded.lookup(file)
+	if !ok {
+		t.Fatal("the saved entry wasn't loaded back")
+	}
+	if !reflect.DeepEqual(got, entry) {
+		t.Fatalf("loaded back %+v, want %+v", got, entry)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatal("saving left its temporary file behind")
+	}
+}
+
+func TestCacheFromAnotherVersionIsIgnored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	contents := `{"Version": 1, "Entries": {"/music/a.mp3": {"Size": 1234, "Format": "MP3", "Title": "Old"}}}`
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewMetadataCache(path)
+	if err := cache.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.Entries) != 0 {
+		t.Fatalf("loaded %d entries from an older cache, want none", len(cache.Entries))
+	}
+}
+
+func TestCacheEntryGoesStaleWhenTheFileChanges(t *testing.T) {
+	modTime := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	file := scannedFile{Path: "/music/a.mp3", Size: 1234, ModTime: modTime}
+	cache := NewMetadataCache("")
+	cache.store(file.Path, CacheEntry{Size: file.Size, ModTime: file.ModTime})
+
+	tests := []struct {
+		name   string
+		file   scannedFile
+		cached bool
+	}{
+		{"unchanged", file, true},
+		{"same time in another zone", scannedFile{Path: file.Path, Size: 1234, ModTime: modTime.In(time.FixedZone("", 3600))}, true},
+		{"resized", scannedFile{Path: file.Path, Size: 1235, ModTime: modTime}, false},
+		{"written to", scannedFile{Path: file.Path, Size: 1234, ModTime: modTime.Add(time.Second)}, false},
+		{"never read", scannedFile{Path: "/music/b.mp3", Size: 1234, ModTime: modTime}, false},
+	}
+	for _, test := range tests {
+		if _, cached := cache.lookup(test.file); cached != test.cached {
+			t.Errorf("%s: cached %v, want %v", test.name, cached, test.cached)
+		}
+	}
+}
+
+func TestCachePruneDropsFilesNoLongerInTheLibrary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	cache := NewMetadataCache(path)
+	kept := scannedFile{Path: "/music/kept.mp3", Size: 1}
+	gone := scannedFile{Path: "/music/gone.mp3", Size: 2}
+	cache.store(kept.Path, CacheEntry{Size: kept.Size})
+	cache.store(gone.Path, CacheEntry{Size: gone.Size})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.prune([]scannedFile{kept})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewMetadataCache(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.lookup(kept); !ok {
+		t.Fatal("pruning dropped a file that is still in the library")
+	}
+	if _, ok := loaded.lookup(gone); ok {
+		t.Fatal("pruning kept a file that has left the library")
+	}
+}
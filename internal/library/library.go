@@ -1,79 +1,448 @@
 package library
 
 import (
+	"errors"
 	"log"
 	"math/rand"
-	"os"
+	"path/filepath"
+	"sync"
 
 	// Internal imports
 	"pomogoro/internal/pomoapp"
 	"pomogoro/internal/song"
 )
 
+const maxHistory = 500 // Most songs remembered for going back through
+
+var ErrEmptyLibrary = errors.New("there are no songs that can be played in the library folders")
+
+// The songs in the library and where playback is up to in them. The player, the transition watcher, the gui and
+// library refreshes all move through it from their own goroutines, so everything about it is guarded by mu. The
+// unexported methods expect mu to already be held.
 type Library struct {
-	LibraryPath        string
-	Songs              []*song.Song
-	CurrentSong        *song.Song
-	CurrIdx            int
 	PlayingCurrentSong bool
 	PlayNextSong       bool
-	HasNextSong        bool
-}
 
-func (library *Library) LoadLibrary(pathToLibrary string, settings *pomoapp.Settings) {
-	library.LibraryPath = pathToLibrary
-	songs, err := os.ReadDir(pathToLibrary)
-	if err != nil {
-		panic(err)
-	}
+	// Songs picked to play next ahead of carrying on through the library
+	Queue *Queue
+
+	// What is known about the files so they don't all have to be read every time the library is loaded
+	Cache *MetadataCache
+
+	mu          sync.Mutex
+	roots       []string // Folders the songs are found in
+	songs       []*song.Song
+	currentSong *song.Song
+	currIdx     int
+	hasNextSong bool
+
+	// Files in the library folder that were left out because they can't be played
+	skippedFiles []string
+
+	// Songs that have been moved away from, most recent last, so going back returns to what was actually played
+	history []int
+
+	// Songs still to be played in this pass through the library while shuffling, next up first
+	shuffleOrder   []int
+	shuffleStarted bool
+	rng            *rand.Rand
+
+	// Folders the last scan walked through, which are the ones watched for changes
+	folders []string
+
+	// Refreshes read the files without holding mu so moving through the library isn't held up, this keeps them from
+	// running over each other
+	refreshMu sync.Mutex
+
+	subscribersMu sync.Mutex
+	subscribers   []func()
+}
 
-	for _, singleSong := range songs {
-		log.Printf("Adding song %s to queue", singleSong.Name())
-		song := song.NewSong(pathToLibrary, singleSong.Name())
-		song.ApplyTag(library.LibraryPath, singleSong.Name())
-		library.Songs = append(
-			library.Songs,
-			song,
-		)
+// Loads every playable song found under the library roots in the settings. When none of the roots could be read or
+// there is nothing in them that can be played an error is returned and the library is left empty without a current
+// song. A root that can't be read is reported even if songs were found in the others.
+func (library *Library) LoadLibrary(settings *pomoapp.Settings) error {
+	library.mu.Lock()
+	if library.Queue == nil {
+		library.Queue = NewQueue()
 	}
+	library.songs = nil
+	library.currentSong = nil
+	library.currIdx = 0
+	library.hasNextSong = false
+	library.skippedFiles = nil
+	library.history = nil
+	library.resetShuffle()
+	library.mu.Unlock()
 
+	err := library.Refresh(settings)
 	log.Print("Finished loading library...")
 
 	// Conditionally initialize the library to a random start point.
-	if settings.Shuffle {
-		library.CurrIdx = rand.Intn(len(library.Songs))
-	} else {
-		library.CurrIdx = 0
+	shuffle := settings.Playback().Shuffle
+	library.mu.Lock()
+	shuffled := shuffle && len(library.songs) > 0
+	if shuffled {
+		library.goTo(library.random().Intn(len(library.songs)))
+	}
+	library.mu.Unlock()
+	if shuffled {
+		library.notify()
+	}
+	return err
+}
+
+// Reads every file in the library again from scratch rather than trusting what is in the cache
+func (library *Library) Rescan(settings *pomoapp.Settings) error {
+	library.cache().Clear()
+	return library.Refresh(settings)
+}
+
+// Scans the library roots again to pick up songs that have been added, removed or changed since it was loaded. Songs
+// that are still there are kept as they are so the one playing and anything queued carry on untouched. Only files
+// that aren't in the cache or have changed since are read, and the library can still be moved through while they are.
+func (library *Library) Refresh(settings *pomoapp.Settings) error {
+	return library.refresh(settings.LibraryFolders())
+}
+
+func (library *Library) refresh(folders pomoapp.LibraryFolders) error {
+	library.refreshMu.Lock()
+	defer library.refreshMu.Unlock()
+
+	cache := library.cache()
+	roots := folders.Roots
+	result, err := scanRoots(roots, folders.IncludePatterns, folders.ExcludePatterns, cache)
+
+	library.mu.Lock()
+	existing := map[string]*song.Song{}
+	for _, librarySong := range library.songs {
+		existing[librarySong.FilePath] = librarySong
 	}
+	library.mu.Unlock()
+
+	songs := make([]*song.Song, 0, len(result.Files))
+	for _, file := range result.Files {
+		entry, cached := cache.lookup(file)
+		if !cached {
+			entry = readEntry(file)
+			cache.store(file.Path, entry)
+		}
+
+		librarySong, ok := existing[file.Path]
+		if !ok {
+			log.Printf("Adding song %s to queue", file.Path)
+			dir, fileName := filepath.Split(file.Path)
+			librarySong = song.NewSong(filepath.Clean(dir), fileName)
+			entry.apply(librarySong)
+		} else if !cached {
+			// Changed since it was last read, most likely retagged
+			entry.apply(librarySong)
+		}
+		songs = append(songs, librarySong)
+	}
+	cache.prune(result.Files)
+	if err := cache.Save(); err != nil {
+		log.Print("Failure in saving the library cache: ", err)
+	}
+
+	// Only refreshes swap the songs out so they are still the ones the existing songs were taken from
+	library.mu.Lock()
+
+	// Work out where every song moved to so the history and shuffle order still point at the same songs
+	newIndexes := map[*song.Song]int{}
+	for i, librarySong := range songs {
+		newIndexes[librarySong] = i
+	}
+	moved := func(oldIndexes []int) []int {
+		var indexes []int
+		for _, oldIndex := range oldIndexes {
+			if newIndex, ok := newIndexes[library.songs[oldIndex]]; ok {
+				indexes = append(indexes, newIndex)
+			}
+		}
+		return indexes
+	}
+	library.history = moved(library.history)
+	library.shuffleOrder = moved(library.shuffleOrder)
+	if library.shuffleStarted {
+		// Songs that are new to the library still get played in this pass
+		for _, librarySong := range songs {
+			if _, ok := existing[librarySong.FilePath]; !ok {
+				position := library.random().Intn(len(library.shuffleOrder) + 1)
+				library.shuffleOrder = append(library.shuffleOrder[:position],
+					append([]int{newIndexes[librarySong]}, library.shuffleOrder[position:]...)...)
+			}
+		}
+	}
+
+	library.roots = roots
+	library.songs = songs
+	library.skippedFiles = result.Skipped
+	library.folders = result.Folders
+	if index, ok := newIndexes[library.currentSong]; ok {
+		library.currIdx = index
+	} else if library.currentSong == nil && len(songs) > 0 {
+		library.goTo(0)
+	} else if library.currIdx >= len(songs) {
+		// The current song is gone from the library but is left alone in case it is still playing. Moving on carries
+		// on from about where it was.
+		library.currIdx = len(songs) - 1
+		if library.currIdx < 0 {
+			library.currIdx = 0
+		}
+	}
+	library.hasNextSong = library.currIdx < len(songs)-1
+	library.mu.Unlock()
+	library.notify()
+
+	if len(songs) == 0 && err == nil {
+		return ErrEmptyLibrary
+	}
+	return err
+}
+
+func (library *Library) cache() *MetadataCache {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	if library.Cache == nil {
+		// Kept in memory only when there's nowhere to save it
+		library.Cache = NewMetadataCache("")
+	}
+	return library.Cache
+}
+
+// Every song in the library in order. A refresh swaps in a new list rather than changing this one so it can be held
+// on to, but it must not be changed.
+func (library *Library) Songs() []*song.Song {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	return library.songs
+}
+
+// The song at the index, false if the library has since changed and there isn't one
+func (library *Library) Song(index int) (*song.Song, bool) {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	if index < 0 || index >= len(library.songs) {
+		return nil, false
+	}
+	return library.songs[index], true
+}
+
+// The song being played or that will be played next, nil if the library is empty
+func (library *Library) CurrentSong() *song.Song {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	return library.currentSong
+}
 
-	// Set the current song as the first one and set the flag that there is a next song
-	library.CurrentSong = library.Songs[library.CurrIdx]
-	library.HasNextSong = true
+// Where the current song is in the library
+func (library *Library) CurrentIndex() int {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	return library.currIdx
+}
+
+// Files in the library folders that were left out because they can't be played
+func (library *Library) SkippedFiles() []string {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	return library.skippedFiles
+}
+
+func (library *Library) watchedFolders() []string {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	return library.folders
+}
+
+// Registers a callback that is run whenever the songs in the library change
+func (library *Library) Subscribe(subscriber func()) {
+	library.subscribersMu.Lock()
+	defer library.subscribersMu.Unlock()
+	library.subscribers = append(library.subscribers, subscriber)
+}
+
+func (library *Library) notify() {
+	library.subscribersMu.Lock()
+	subscribers := library.subscribers
+	library.subscribersMu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber()
+	}
 }
 
 func (library *Library) DecIndex() {
-	if library.CurrIdx-1 > 0 {
-		library.CurrIdx = library.CurrIdx - 1
-		library.CurrentSong = library.Songs[library.CurrIdx]
-		library.HasNextSong = true
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	if library.currIdx > 0 && library.currIdx < len(library.songs) {
+		library.setCurrent(library.currIdx - 1)
 	}
 }
 
+// Moves on to the next song, going back around to the first one after the last
 func (library *Library) IncIndex() {
-	library.CurrIdx = library.CurrIdx + 1
-	library.CurrentSong = library.Songs[library.CurrIdx]
-	if library.CurrIdx >= len(library.Songs)-1 {
-		library.HasNextSong = false
-	} else {
-		library.HasNextSong = true
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	if len(library.songs) == 0 {
+		return
+	}
+	library.setCurrent((library.currIdx + 1) % len(library.songs))
+}
+
+// Makes the song at the index the current one, such as when it is picked out of the library list
+func (library *Library) Select(index int) {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	if index < 0 || index >= len(library.songs) {
+		return
+	}
+	library.setCurrent(index)
+}
+
+// Index of the song that would be moved on to next without moving to it, false if there isn't one. Songs waiting in
+// the queue come first, then the library wraps back around to the start when repeating all of it.
+func (library *Library) PeekNext(settings *pomoapp.Settings) (int, bool) {
+	playback := settings.Playback()
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	return library.peekNext(playback)
+}
+
+func (library *Library) peekNext(playback pomoapp.Playback) (int, bool) {
+	if queued, ok := library.Queue.Peek(); ok {
+		if index := library.indexOf(queued); index >= 0 {
+			return index, true
+		}
+	}
+	if playback.Shuffle {
+		return library.peekShuffle(playback.Repeat == pomoapp.RepeatAll)
+	}
+	if library.hasNextSong {
+		return library.currIdx + 1, true
+	}
+	if playback.Repeat == pomoapp.RepeatAll && len(library.songs) > 0 {
+		return 0, true
+	}
+	return library.currIdx, false
+}
+
+// The song that would be moved on to next, the same as PeekNext
+func (library *Library) PeekNextSong(settings *pomoapp.Settings) (*song.Song, bool) {
+	playback := settings.Playback()
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	index, ok := library.peekNext(playback)
+	if !ok {
+		return nil, false
 	}
+	return library.songs[index], true
+}
+
+// Whether there is anything to move on to, either in the queue or the library
+func (library *Library) HasNext(settings *pomoapp.Settings) bool {
+	_, ok := library.PeekNext(settings)
+	return ok
+}
+
+// Moves on to the song at the front of the queue, or the next one in the library if the queue is empty. Returns false
+// if there was nothing to move on to.
+func (library *Library) Next(settings *pomoapp.Settings) bool {
+	playback := settings.Playback()
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	for {
+		queued, ok := library.Queue.Pop()
+		if !ok {
+			break
+		}
+		// Songs that have since left the library are passed over
+		if index := library.indexOf(queued); index >= 0 {
+			library.setCurrent(index)
+			return true
+		}
+	}
+
+	index, ok := library.peekNext(playback)
+	if !ok {
+		return false
+	}
+	library.setCurrent(index)
+	return true
+}
+
+// Moves on to the song, such as one that has already started playing, taking it off the queue if it was at the front.
+// Returns false if the song is no longer in the library.
+func (library *Library) MoveTo(target *song.Song) bool {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	index := library.indexOf(target)
+	if index < 0 {
+		return false
+	}
+	if queued, ok := library.Queue.Peek(); ok && queued == target {
+		library.Queue.Pop()
+	}
+	library.setCurrent(index)
+	return true
+}
+
+// Whether there is a song to go back to
+func (library *Library) HasPrevious(settings *pomoapp.Settings) bool {
+	shuffle := settings.Playback().Shuffle
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	return len(library.history) > 0 || (!shuffle && library.currIdx > 0 && library.currIdx < len(library.songs))
+}
+
+// Goes back to the song played before the current one. Without any history to go on it steps back through the library
+// unless shuffling. Returns false if there was nothing to go back to.
+func (library *Library) Previous(settings *pomoapp.Settings) bool {
+	shuffle := settings.Playback().Shuffle
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	if len(library.history) == 0 {
+		if shuffle || library.currIdx <= 0 || library.currIdx >= len(library.songs) {
+			return false
+		}
+		library.goTo(library.currIdx - 1)
+		return true
+	}
+
+	previous := library.history[len(library.history)-1]
+	library.history = library.history[:len(library.history)-1]
+	if shuffle {
+		// The song being left hasn't really been listened to so shuffle comes back to it next
+		library.shuffleOrder = append([]int{library.currIdx}, library.shuffleOrder...)
+	}
+	library.goTo(previous)
+	return true
+}
+
+// Moves to the song, remembering the one being left so it can be gone back to
+func (library *Library) setCurrent(index int) {
+	if library.currentSong != nil && index != library.currIdx {
+		library.history = append(library.history, library.currIdx)
+		if len(library.history) > maxHistory {
+			library.history = library.history[len(library.history)-maxHistory:]
+		}
+	}
+	library.goTo(index)
+}
+
+func (library *Library) goTo(index int) {
+	library.currIdx = index
+	library.currentSong = library.songs[library.currIdx]
+	library.hasNextSong = library.currIdx < len(library.songs)-1
+	library.removeFromShuffle(index)
 }
 
-func (library *Library) NextShuffle() {
-	currIdx := rand.Intn(len(library.Songs))
-	for currIdx == library.CurrIdx {
-		currIdx = rand.Intn(len(library.Songs))
+func (library *Library) indexOf(target *song.Song) int {
+	for i, librarySong := range library.songs {
+		if librarySong == target {
+			return i
+		}
 	}
-	library.CurrIdx = currIdx
-	library.CurrentSong = library.Songs[library.CurrIdx]
+	return -1
 }
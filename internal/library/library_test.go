@@ -0,0 +1,101 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	// Internal imports
+	"pomogoro/internal/pomoapp"
+)
+
+// Meant to be run with -race. The watcher refreshes the library on its own goroutine while the player and gui keep
+// moving through it.
+func TestRefreshWhileMovingThroughTheLibrary(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		writeTestSong(t, filepath.Join(dir, fmt.Sprintf("%02d.wav", i)))
+	}
+	settings := &pomoapp.Settings{LibraryRoots: []string{dir}, Shuffle: true, Repeat: pomoapp.RepeatAll}
+	library := &Library{}
+	if err := library.LoadLibrary(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			// Songs come and go between refreshes so the indexes keep shifting
+			path := filepath.Join(dir, fmt.Sprintf("new%02d.wav", i/2))
+			var err error
+			if i%2 == 0 {
+				err = os.WriteFile(path, []byte("RIFF\x00\x00\x00\x00WAVE"), 0644)
+			} else {
+				err = os.Remove(path)
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err := library.Refresh(settings); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 500; i++ {
+		switch i % 4 {
+		case 0, 1:
+			library.Next(settings)
+		case 2:
+			library.Previous(settings)
+		case 3:
+			if next, ok := library.PeekNextSong(settings); ok {
+				library.Queue.Enqueue(next)
+			}
+		}
+		if library.CurrentSong() == nil {
+			t.Fatal("library lost its current song")
+		}
+	}
+	wg.Wait()
+}
+
+func TestEmptiedLibraryHasNothingToGoBackTo(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		writeTestSong(t, filepath.Join(dir, fmt.Sprintf("%02d.wav", i)))
+	}
+	settings := &pomoapp.Settings{LibraryRoots: []string{dir}}
+	library := &Library{}
+	if err := library.LoadLibrary(settings); err != nil {
+		t.Fatal(err)
+	}
+	library.Next(settings)
+	library.Next(settings)
+
+	// Every song is deleted while the last one is current
+	for i := 0; i < 3; i++ {
+		if err := os.Remove(filepath.Join(dir, fmt.Sprintf("%02d.wav", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := library.Refresh(settings); err != ErrEmptyLibrary {
+		t.Fatalf("refreshing an emptied library returned %v, want ErrEmptyLibrary", err)
+	}
+	if index := library.CurrentIndex(); index != 0 {
+		t.Fatalf("current index %d, want 0", index)
+	}
+	if library.HasPrevious(settings) || library.Previous(settings) {
+		t.Fatal("an empty library has a song to go back to")
+	}
+	library.DecIndex()
+	if library.HasNext(settings) || library.Next(settings) {
+		t.Fatal("an empty library has a song to move on to")
+	}
+}
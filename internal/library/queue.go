@@ -0,0 +1,121 @@
+package library
+
+import (
+	"sync"
+
+	// Internal imports
+	"pomogoro/internal/song"
+)
+
+// Songs the user has picked to play next, in order. Anything in the queue is played before carrying on through the
+// library.
+type Queue struct {
+	mu          sync.Mutex
+	songs       []*song.Song
+	subscribers []func()
+}
+
+func NewQueue() *Queue {
+	return &Queue{}
+}
+
+// Registers a callback that is run whenever the queue changes
+func (queue *Queue) Subscribe(subscriber func()) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	queue.subscribers = append(queue.subscribers, subscriber)
+}
+
+// Copy of the songs waiting in the queue, next up first
+func (queue *Queue) Songs() []*song.Song {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return append([]*song.Song{}, queue.songs...)
+}
+
+func (queue *Queue) Len() int {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return len(queue.songs)
+}
+
+// Adds songs to the end of the queue
+func (queue *Queue) Enqueue(songs ...*song.Song) {
+	queue.change(func() {
+		queue.songs = append(queue.songs, songs...)
+	})
+}
+
+// Puts a song at the front of the queue so it plays once the current one is done
+func (queue *Queue) PlayNext(next *song.Song) {
+	queue.change(func() {
+		queue.songs = append([]*song.Song{next}, queue.songs...)
+	})
+}
+
+// Moves the song at one position in the queue to another, shuffling the ones in between along
+func (queue *Queue) Move(from int, to int) {
+	queue.change(func() {
+		if from < 0 || from >= len(queue.songs) || from == to {
+			return
+		}
+		if to < 0 {
+			to = 0
+		} else if to >= len(queue.songs) {
+			to = len(queue.songs) - 1
+		}
+
+		moved := queue.songs[from]
+		queue.songs = append(queue.songs[:from], queue.songs[from+1:]...)
+		queue.songs = append(queue.songs[:to], append([]*song.Song{moved}, queue.songs[to:]...)...)
+	})
+}
+
+func (queue *Queue) Remove(index int) {
+	queue.change(func() {
+		if index < 0 || index >= len(queue.songs) {
+			return
+		}
+		queue.songs = append(queue.songs[:index], queue.songs[index+1:]...)
+	})
+}
+
+func (queue *Queue) Clear() {
+	queue.change(func() {
+		queue.songs = nil
+	})
+}
+
+// The song at the front of the queue without taking it off
+func (queue *Queue) Peek() (*song.Song, bool) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	if len(queue.songs) == 0 {
+		return nil, false
+	}
+	return queue.songs[0], true
+}
+
+// Takes the song at the front of the queue off to be played
+func (queue *Queue) Pop() (*song.Song, bool) {
+	var next *song.Song
+	queue.change(func() {
+		if len(queue.songs) > 0 {
+			next = queue.songs[0]
+			queue.songs = queue.songs[1:]
+		}
+	})
+	return next, next != nil
+}
+
+// Applies the change under the lock and then lets the subscribers know
+func (queue *Queue) change(apply func()) {
+	queue.mu.Lock()
+	apply()
+	subscribers := queue.subscribers
+	queue.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber()
+	}
+}
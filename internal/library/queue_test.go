@@ -0,0 +1,109 @@
+package library
+
+import (
+	"testing"
+
+	// Internal imports
+	"pomogoro/internal/song"
+)
+
+func TestQueueChanges(t *testing.T) {
+	a := song.NewSong("/music", "a.mp3")
+	b := song.NewSong("/music", "b.mp3")
+	c := song.NewSong("/music", "c.mp3")
+	d := song.NewSong("/music", "d.mp3")
+
+	tests := []struct {
+		name    string
+		change  func(queue *Queue)
+		want    []*song.Song
+		notices int // How many times the subscriber hears about it
+	}{
+		{"enqueue", func(queue *Queue) { queue.Enqueue(d) }, []*song.Song{a, b, c, d}, 1},
+		{"enqueue several", func(queue *Queue) { queue.Enqueue(d, a) }, []*song.Song{a, b, c, d, a}, 1},
+		{"play next", func(queue *Queue) { queue.PlayNext(d) }, []*song.Song{d, a, b, c}, 1},
+		{"remove first", func(queue *Queue) { queue.Remove(0) }, []*song.Song{b, c}, 1},
+		{"remove middle", func(queue *Queue) { queue.Remove(1) }, []*song.Song{a, c}, 1},
+		{"remove last", func(queue *Queue) { queue.Remove(2) }, []*song.Song{a, b}, 1},
+		{"remove before start", func(queue *Queue) { queue.Remove(-1) }, []*song.Song{a, b, c}, 1},
+		{"remove past end", func(queue *Queue) { queue.Remove(3) }, []*song.Song{a, b, c}, 1},
+		{"move down", func(queue *Queue) { queue.Move(0, 2) }, []*song.Song{b, c, a}, 1},
+		{"move up", func(queue *Queue) { queue.Move(2, 0) }, []*song.Song{c, a, b}, 1},
+		{"move one along", func(queue *Queue) { queue.Move(0, 1) }, []*song.Song{b, a, c}, 1},
+		{"move to itself", func(queue *Queue) { queue.Move(1, 1) }, []*song.Song{a, b, c}, 1},
+		{"move before start", func(queue *Queue) { queue.Move(2, -5) }, []*song.Song{c, a, b}, 1},
+		{"move past end", func(queue *Queue) { queue.Move(0, 10) }, []*song.Song{b, c, a}, 1},
+		{"move from past end", func(queue *Queue) { queue.Move(3, 0) }, []*song.Song{a, b, c}, 1},
+		{"move from before start", func(queue *Queue) { queue.Move(-1, 0) }, []*song.Song{a, b, c}, 1},
+		{"pop", func(queue *Queue) { queue.Pop() }, []*song.Song{b, c}, 1},
+		{"pop all", func(queue *Queue) { queue.Pop(); queue.Pop(); queue.Pop(); queue.Pop() }, nil, 4},
+		{"clear", func(queue *Queue) { queue.Clear() }, nil, 1},
+		{"peek", func(queue *Queue) { queue.Peek() }, []*song.Song{a, b, c}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queue := NewQueue()
+			queue.Enqueue(a, b, c)
+			notices := 0
+			queue.Subscribe(func() {
+				notices += 1
+			})
+
+			test.change(queue)
+			got := queue.Songs()
+			if len(got) != len(test.want) || queue.Len() != len(test.want) {
+				t.Fatalf("queue holds %v, want %v", names(got), names(test.want))
+			}
+			for i := range test.want {
+				if got[i] != test.want[i] {
+					t.Fatalf("queue holds %v, want %v", names(got), names(test.want))
+				}
+			}
+			if notices != test.notices {
+				t.Fatalf("subscriber was told %d times, want %d", notices, test.notices)
+			}
+		})
+	}
+}
+
+func TestQueuePopAndPeekTakeFromTheFront(t *testing.T) {
+	a := song.NewSong("/music", "a.mp3")
+	b := song.NewSong("/music", "b.mp3")
+	queue := NewQueue()
+	if _, ok := queue.Peek(); ok {
+		t.Fatal("an empty queue has something to peek at")
+	}
+
+	queue.Enqueue(a)
+	queue.PlayNext(b)
+	for _, want := range []*song.Song{b, a} {
+		if next, ok := queue.Peek(); !ok || next != want {
+			t.Fatalf("peeked at %v, want %s", next, want.Name)
+		}
+		if next, ok := queue.Pop(); !ok || next != want {
+			t.Fatalf("popped %v, want %s", next, want.Name)
+		}
+	}
+	if next, ok := queue.Pop(); ok {
+		t.Fatalf("popped %s off an empty queue", next.Name)
+	}
+}
+
+func TestQueueSongsIsACopy(t *testing.T) {
+	a := song.NewSong("/music", "a.mp3")
+	queue := NewQueue()
+	queue.Enqueue(a)
+	songs := queue.Songs()
+	songs[0] = nil
+	if next, ok := queue.Peek(); !ok || next != a {
+		t.Fatal("changing the songs handed out changed the queue")
+	}
+}
+
+func names(songs []*song.Song) []string {
+	var names []string
+	for _, queued := range songs {
+		names = append(names, queued.Name)
+	}
+	return names
+}
@@ -0,0 +1,148 @@
+package library
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+)
+
+// Checks that every pattern is a valid glob so a typo is caught before it silently matches nothing
+func ValidatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q is not a valid pattern", pattern)
+		}
+	}
+	return nil
+}
+
+// Playable file found while scanning
+type scannedFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Format  string
+}
+
+// What a scan of the library roots turned up
+type scanResult struct {
+	Files   []scannedFile // Playable files in the order they were found
+	Skipped []string      // Files that were included but aren't in a supported format
+	Folders []string      // Every folder that was walked, roots included
+}
+
+type scanner struct {
+	include []string
+	exclude []string
+	cache   *MetadataCache // Files it already knows about don't have their format checked again
+
+	// Folders already walked by their real path, so a symlink pointing back up the tree or two roots that overlap
+	// don't get walked again
+	visited map[string]bool
+	result  scanResult
+}
+
+// Walks the folders under the library roots for playable files. Patterns are globs matched against both the name and
+// the path relative to its root, such as "*.flac" or "Podcasts/*". A file has to match one of the include patterns
+// when there are any, and anything matching an exclude pattern is left out along with everything under it.
+func scanRoots(roots []string, include []string, exclude []string, cache *MetadataCache) (scanResult, error) {
+	s := &scanner{
+		include: include,
+		exclude: exclude,
+		cache:   cache,
+		visited: map[string]bool{},
+	}
+
+	// Every root is scanned even if an earlier one couldn't be read so one missing drive doesn't empty the library
+	var firstErr error
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a folder", root)
+		}
+		if err != nil {
+			log.Printf("Could not scan library folder %s: %v", root, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("could not read the library folder: %w", err)
+			}
+			continue
+		}
+		s.walk(root, root)
+	}
+	return s.result, firstErr
+}
+
+func (s *scanner) walk(root string, dir string) {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		log.Printf("Skipping folder %s: %v", dir, err)
+		return
+	}
+	if s.visited[realDir] {
+		return
+	}
+	s.visited[realDir] = true
+	s.result.Folders = append(s.result.Folders, dir)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// Carry on with the rest of the library rather than losing all of it to one unreadable folder
+		log.Printf("Skipping folder %s: %v", dir, err)
+		return
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(dir, entry.Name())
+		relativePath, _ := filepath.Rel(root, entryPath)
+		if matchesAny(s.exclude, entry.Name(), relativePath) {
+			continue
+		}
+
+		// Symlinks are followed to whatever they point at
+		info, err := os.Stat(entryPath)
+		if err != nil {
+			log.Printf("Skipping %s: %v", entryPath, err)
+			continue
+		}
+
+		if info.IsDir() {
+			s.walk(root, entryPath)
+			continue
+		}
+		if len(s.include) > 0 && !matchesAny(s.include, entry.Name(), relativePath) {
+			continue
+		}
+
+		file := scannedFile{Path: entryPath, Size: info.Size(), ModTime: info.ModTime()}
+		if cached, ok := s.cache.lookup(file); ok {
+			file.Format = cached.Format
+		} else {
+			format, err := audio.DetectFormat(entryPath)
+			if err != nil {
+				log.Printf("Skipping %s: %v", entryPath, err)
+				s.result.Skipped = append(s.result.Skipped, entryPath)
+				continue
+			}
+			file.Format = format.Name
+		}
+		s.result.Files = append(s.result.Files, file)
+	}
+}
+
+func matchesAny(patterns []string, name string, relativePath string) bool {
+	relativePath = filepath.ToSlash(relativePath)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, relativePath); matched {
+			return true
+		}
+	}
+	return false
+}
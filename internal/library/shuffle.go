@@ -0,0 +1,72 @@
+package library
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Replaces the random source shuffle uses so the order it plays in can be repeated
+func (library *Library) Seed(seed int64) {
+	library.mu.Lock()
+	defer library.mu.Unlock()
+	library.rng = rand.New(rand.NewSource(seed))
+	library.resetShuffle()
+}
+
+func (library *Library) random() *rand.Rand {
+	if library.rng == nil {
+		library.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return library.rng
+}
+
+// Song shuffle will play next without moving to it. Every song is played once per pass through the library before
+// any of them comes around again, and the library only starts over when repeating all of it.
+func (library *Library) peekShuffle(repeatAll bool) (int, bool) {
+	if len(library.shuffleOrder) == 0 {
+		if library.shuffleStarted && !repeatAll {
+			return library.currIdx, false
+		}
+		library.newShufflePass()
+	}
+	if len(library.shuffleOrder) == 0 {
+		// The only song in the library is the one playing
+		return library.currIdx, repeatAll && len(library.songs) > 0
+	}
+	return library.shuffleOrder[0], true
+}
+
+// Deals out a new order for every song in the library. The first pass leaves out the song already playing since it
+// counts towards it, and later passes avoid starting with it so it isn't heard twice in a row.
+func (library *Library) newShufflePass() {
+	order := library.random().Perm(len(library.songs))
+	if !library.shuffleStarted {
+		for i, index := range order {
+			if index == library.currIdx {
+				order = append(order[:i], order[i+1:]...)
+				break
+			}
+		}
+	} else if len(order) > 1 && order[0] == library.currIdx {
+		last := len(order) - 1
+		order[0], order[last] = order[last], order[0]
+	}
+	library.shuffleOrder = order
+	library.shuffleStarted = true
+}
+
+// Takes a song out of what is left of the pass once it has been played, however it came to be played
+func (library *Library) removeFromShuffle(index int) {
+	for i, upcoming := range library.shuffleOrder {
+		if upcoming == index {
+			library.shuffleOrder = append(library.shuffleOrder[:i], library.shuffleOrder[i+1:]...)
+			return
+		}
+	}
+}
+
+// Starts shuffling again from scratch, such as after the songs in the library have changed
+func (library *Library) resetShuffle() {
+	library.shuffleOrder = nil
+	library.shuffleStarted = false
+}
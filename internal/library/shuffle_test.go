@@ -0,0 +1,186 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// Internal imports
+	"pomogoro/internal/pomoapp"
+	"pomogoro/internal/song"
+)
+
+// Library of songs that only exist in memory, starting on the first one
+func newMemoryLibrary(count int, seed int64) *Library {
+	library := &Library{Queue: NewQueue()}
+	for i := 0; i < count; i++ {
+		library.songs = append(library.songs, song.NewSong("/music", fmt.Sprintf("%02d.mp3", i)))
+	}
+	library.Seed(seed)
+	library.goTo(0)
+	return library
+}
+
+// Moves on through the library, returning the index of every song landed on
+func playThrough(t *testing.T, library *Library, settings *pomoapp.Settings, count int) []int {
+	t.Helper()
+	var played []int
+	for i := 0; i < count; i++ {
+		if !library.Next(settings) {
+			t.Fatalf("ran out of songs after %d of %d", i, count)
+		}
+		played = append(played, library.CurrentIndex())
+	}
+	return played
+}
+
+func TestShufflePlaysEverySongOncePerPass(t *testing.T) {
+	tests := []struct {
+		songs int
+		seed  int64
+	}{
+		{songs: 2, seed: 1},
+		{songs: 5, seed: 2},
+		{songs: 5, seed: 3},
+		{songs: 40, seed: 4},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d songs seed %d", test.songs, test.seed), func(t *testing.T) {
+			library := newMemoryLibrary(test.songs, test.seed)
+			settings := &pomoapp.Settings{Shuffle: true, Repeat: pomoapp.RepeatAll}
+
+			// The song it started on counts towards the first pass
+			start := library.CurrentIndex()
+			firstPass := append([]int{start}, playThrough(t, library, settings, test.songs-1)...)
+			secondPass := playThrough(t, library, settings, test.songs)
+			for pass, played := range [][]int{firstPass, secondPass} {
+				seen := map[int]bool{}
+				for _, index := range played {
+					if seen[index] {
+						t.Fatalf("pass %d played %d twice: %v", pass+1, index, played)
+					}
+					seen[index] = true
+				}
+				if len(seen) != test.songs {
+					t.Fatalf("pass %d played %d of %d songs: %v", pass+1, len(seen), test.songs, played)
+				}
+			}
+			if secondPass[0] == firstPass[len(firstPass)-1] {
+				t.Fatalf("%d was played twice in a row across passes", secondPass[0])
+			}
+		})
+	}
+}
+
+func TestShuffleStopsAfterOnePassWithoutRepeat(t *testing.T) {
+	library := newMemoryLibrary(6, 7)
+	settings := &pomoapp.Settings{Shuffle: true}
+
+	playThrough(t, library, settings, 5)
+	if library.HasNext(settings) || library.Next(settings) {
+		t.Fatal("shuffle carried on past the end of the pass without repeating")
+	}
+}
+
+func TestShuffleOneSongLibrary(t *testing.T) {
+	tests := []struct {
+		repeat pomoapp.RepeatMode
+		want   bool
+	}{
+		{repeat: pomoapp.RepeatOff, want: false},
+		{repeat: pomoapp.RepeatAll, want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.repeat.String(), func(t *testing.T) {
+			library := newMemoryLibrary(1, 1)
+			settings := &pomoapp.Settings{Shuffle: true, Repeat: test.repeat}
+			for i := 0; i < 3; i++ {
+				if got := library.Next(settings); got != test.want {
+					t.Fatalf("Next returned %v, want %v", got, test.want)
+				}
+				if library.CurrentIndex() != 0 {
+					t.Fatalf("moved to %d in a library of one song", library.CurrentIndex())
+				}
+			}
+		})
+	}
+}
+
+func TestShuffleSeedRepeatsTheOrder(t *testing.T) {
+	settings := &pomoapp.Settings{Shuffle: true, Repeat: pomoapp.RepeatAll}
+	first := playThrough(t, newMemoryLibrary(20, 42), settings, 30)
+	second := playThrough(t, newMemoryLibrary(20, 42), settings, 30)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("the same seed played %v and then %v", first, second)
+		}
+	}
+}
+
+func TestPreviousGoesBackThroughWhatWasPlayed(t *testing.T) {
+	for _, shuffle := range []bool{false, true} {
+		t.Run(fmt.Sprintf("shuffle %v", shuffle), func(t *testing.T) {
+			library := newMemoryLibrary(10, 5)
+			settings := &pomoapp.Settings{Shuffle: shuffle}
+			start := library.CurrentIndex()
+			played := append([]int{start}, playThrough(t, library, settings, 4)...)
+
+			for i := len(played) - 2; i >= 0; i-- {
+				if !library.Previous(settings) {
+					t.Fatalf("could not go back to %d", played[i])
+				}
+				if library.CurrentIndex() != played[i] {
+					t.Fatalf("went back to %d, want %d from %v", library.CurrentIndex(), played[i], played)
+				}
+			}
+			if library.HasPrevious(settings) {
+				t.Fatal("there is still something to go back to before the first song")
+			}
+
+			// Going forward again picks up with the songs that were gone back over
+			if !library.Next(settings) || library.CurrentIndex() != played[1] {
+				t.Fatalf("went forward to %d, want %d", library.CurrentIndex(), played[1])
+			}
+		})
+	}
+}
+
+func TestRefreshKeepsHistoryOnTheSameSongs(t *testing.T) {
+	dir := t.TempDir()
+	writeSong := func(name string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("RIFF\x00\x00\x00\x00WAVE"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"a.wav", "b.wav", "c.wav", "d.wav", "e.wav"} {
+		writeSong(name)
+	}
+	settings := &pomoapp.Settings{LibraryRoots: []string{dir}}
+	library := &Library{}
+	if err := library.LoadLibrary(settings); err != nil {
+		t.Fatal(err)
+	}
+	playThrough(t, library, settings, 3)
+
+	// Take one of the songs gone through out and put a new one in ahead of all of them
+	if err := os.Remove(filepath.Join(dir, "b.wav")); err != nil {
+		t.Fatal(err)
+	}
+	writeSong("0.wav")
+	if err := library.Refresh(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	if library.CurrentSong().Name != "d.wav" || library.Songs()[library.CurrentIndex()] != library.CurrentSong() {
+		t.Fatalf("current song is %s at %d after refreshing, want d.wav", library.CurrentSong().Name, library.CurrentIndex())
+	}
+	for _, want := range []string{"c.wav", "a.wav"} {
+		if !library.Previous(settings) {
+			t.Fatalf("could not go back to %s", want)
+		}
+		if library.CurrentSong().Name != want {
+			t.Fatalf("went back to %s, want %s", library.CurrentSong().Name, want)
+		}
+	}
+}
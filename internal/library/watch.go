@@ -0,0 +1,181 @@
+package library
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/pomoapp"
+
+	// File watching
+	"github.com/fsnotify/fsnotify"
+)
+
+const refreshDelay = time.Second // How long the library folders have to be left alone before a refresh happens
+
+// A refresh asked for from outside the watcher, answered with how it went
+type refreshRequest struct {
+	folders pomoapp.LibraryFolders
+	rescan  bool // Whether to read every file again rather than trusting the cache
+	result  chan error
+}
+
+// Keeps the library in step with the files in its folders. Changes are gathered up until the folders have been quiet
+// for a moment so copying in a whole album only refreshes the library once. Every refresh happens on the watcher's own
+// goroutine, including ones asked for by the gui, so the folders being watched always follow the latest scan.
+type Watcher struct {
+	library   *Library
+	settings  *pomoapp.Settings
+	fsWatcher *fsnotify.Watcher
+	folders   pomoapp.LibraryFolders // Copied from the settings whenever a refresh is asked for, only used by run
+
+	mu       sync.Mutex
+	watched  map[string]bool
+	timer    *time.Timer
+	due      chan struct{}       // Signalled once the folders have been quiet long enough to refresh
+	requests chan refreshRequest // Refreshes asked for straight away
+	done     chan struct{}
+}
+
+// Starts watching every folder the library was loaded from
+func (library *Library) Watch(settings *pomoapp.Settings) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &Watcher{
+		library:   library,
+		settings:  settings,
+		fsWatcher: fsWatcher,
+		folders:   settings.LibraryFolders(),
+		watched:   map[string]bool{},
+		due:       make(chan struct{}, 1),
+		requests:  make(chan refreshRequest),
+		done:      make(chan struct{}),
+	}
+	watcher.watchFolders(library.watchedFolders())
+	go watcher.run()
+	return watcher, nil
+}
+
+func (watcher *Watcher) Close() error {
+	close(watcher.done)
+	watcher.mu.Lock()
+	if watcher.timer != nil {
+		watcher.timer.Stop()
+	}
+	watcher.mu.Unlock()
+	return watcher.fsWatcher.Close()
+}
+
+func (watcher *Watcher) run() {
+	for {
+		select {
+		case <-watcher.done:
+			return
+		case _, ok := <-watcher.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			watcher.changed()
+		case err, ok := <-watcher.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			log.Print("Failure in watching the library folders: ", err)
+		case <-watcher.due:
+			log.Print("Library folders changed, refreshing library...")
+			if err := watcher.refresh(); err != nil {
+				log.Print("Failure in refreshing the library: ", err)
+			}
+		case request := <-watcher.requests:
+			// Later refreshes from the folders changing carry on with whatever the settings were at the time
+			watcher.folders = request.folders
+			if request.rescan {
+				watcher.library.cache().Clear()
+			}
+			request.result <- watcher.refresh()
+		}
+	}
+}
+
+// Refreshes the library straight away, such as after the library folders have been changed in the settings, and waits
+// for it to finish. The folders are read from the settings on the calling goroutine. Nothing happens once the watcher
+// has been closed.
+func (watcher *Watcher) Refresh() error {
+	return watcher.request(false)
+}
+
+// Reads every file in the library again the same as Library.Rescan, then watches whichever folders it found
+func (watcher *Watcher) Rescan() error {
+	return watcher.request(true)
+}
+
+func (watcher *Watcher) request(rescan bool) error {
+	result := make(chan error, 1)
+	select {
+	case watcher.requests <- refreshRequest{folders: watcher.settings.LibraryFolders(), rescan: rescan, result: result}:
+	case <-watcher.done:
+		return nil
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-watcher.done:
+		return nil
+	}
+}
+
+// Pushes the refresh back until things settle. Files that were written to are picked up by the refresh noticing that
+// they have changed since they were cached.
+func (watcher *Watcher) changed() {
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+
+	if watcher.timer != nil {
+		watcher.timer.Stop()
+	}
+	watcher.timer = time.AfterFunc(refreshDelay, func() {
+		// A refresh that is already waiting to happen covers this one too
+		select {
+		case watcher.due <- struct{}{}:
+		default:
+		}
+	})
+}
+
+func (watcher *Watcher) refresh() error {
+	err := watcher.library.refresh(watcher.folders)
+
+	// Folders created or added to the settings since the last scan need watching too
+	watcher.watchFolders(watcher.library.watchedFolders())
+	return err
+}
+
+// Watches the folders that aren't already and stops watching the ones that are no longer part of the library
+func (watcher *Watcher) watchFolders(folders []string) {
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+
+	current := map[string]bool{}
+	for _, folder := range folders {
+		current[folder] = true
+		if watcher.watched[folder] {
+			continue
+		}
+		if err := watcher.fsWatcher.Add(folder); err != nil {
+			log.Printf("Could not watch %s for changes: %v", folder, err)
+			continue
+		}
+		watcher.watched[folder] = true
+	}
+	for folder := range watcher.watched {
+		if !current[folder] {
+			// The folder may already be gone in which case it has stopped being watched anyway
+			_ = watcher.fsWatcher.Remove(folder)
+			delete(watcher.watched, folder)
+		}
+	}
+}
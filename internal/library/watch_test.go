@@ -0,0 +1,62 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/pomoapp"
+)
+
+func writeTestSong(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("RIFF\x00\x00\x00\x00WAVE"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherFollowsRootsAddedToTheSettings(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeTestSong(t, filepath.Join(first, "a.wav"))
+	writeTestSong(t, filepath.Join(second, "b.wav"))
+
+	settings := &pomoapp.Settings{LibraryRoots: []string{first}}
+	library := &Library{}
+	if err := library.LoadLibrary(settings); err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := library.Watch(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	refreshed := make(chan int, 10)
+	library.Subscribe(func() {
+		refreshed <- len(library.Songs())
+	})
+
+	settings.LibraryRoots = append(settings.LibraryRoots, second)
+	if err := watcher.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if count := <-refreshed; count != 2 {
+		t.Fatalf("library has %d songs after refreshing, want 2", count)
+	}
+
+	// The new root is watched as well so a song copied into it turns up by itself
+	writeTestSong(t, filepath.Join(second, "c.wav"))
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case count := <-refreshed:
+			if count == 3 {
+				return
+			}
+		case <-timeout:
+			t.Fatal("song added to the new root was never picked up")
+		}
+	}
+}
@@ -1,9 +1,145 @@
 package messages
 
-type ChannelMessage struct {
-	SongFinished bool
-	SongSkipped  bool
-	SongStopped  bool
-	SongPaused   bool
-	SongResumed  bool
+import (
+	"sync"
+	"time"
+)
+
+// What happened to the song being played
+type EventKind int
+
+const (
+	TrackStarted EventKind = iota
+	TrackPaused
+	TrackResumed
+	TrackFinished // Played all the way through to the end
+	TrackSkipped
+	TrackStopped
+	TrackError // Couldn't be played or broke part way through, Err says why
+)
+
+func (kind EventKind) String() string {
+	switch kind {
+	case TrackStarted:
+		return "track_started"
+	case TrackPaused:
+		return "track_paused"
+	case TrackResumed:
+		return "track_resumed"
+	case TrackFinished:
+		return "track_finished"
+	case TrackSkipped:
+		return "track_skipped"
+	case TrackStopped:
+		return "track_stopped"
+	default:
+		return "track_error"
+	}
+}
+
+// Something that happened during playback along with the song it happened to
+type Event struct {
+	Kind     EventKind
+	Time     time.Time
+	SongName string
+	FilePath string
+	Position time.Duration // How far into the song it happened
+	Err      error         // Only set for TrackError
+}
+
+// Callback that is notified of every playback event
+type Subscriber func(event Event)
+
+type subscription struct {
+	id         int
+	subscriber Subscriber
+}
+
+// Hands playback events out to everything that has subscribed. Publishing never blocks, the events are queued up and
+// delivered in order on the bus's own goroutine so a slow subscriber can't hold up the song playing.
+type Bus struct {
+	mu            sync.Mutex
+	wake          *sync.Cond
+	queue         []Event
+	subscriptions []subscription
+	nextID        int
+	closed        bool
+	done          chan struct{}
+}
+
+func NewBus() *Bus {
+	bus := &Bus{done: make(chan struct{})}
+	bus.wake = sync.NewCond(&bus.mu)
+	go bus.deliver()
+	return bus
+}
+
+// Registers a subscriber, returning the function to call to stop it being notified
+func (bus *Bus) Subscribe(subscriber Subscriber) func() {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	id := bus.nextID
+	bus.nextID += 1
+	bus.subscriptions = append(bus.subscriptions, subscription{id: id, subscriber: subscriber})
+
+	return func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		for i, subscription := range bus.subscriptions {
+			if subscription.id == id {
+				bus.subscriptions = append(bus.subscriptions[:i], bus.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Queues the event up for the subscribers. Anything published after the bus is closed is dropped.
+func (bus *Bus) Publish(event Event) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.closed {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	bus.queue = append(bus.queue, event)
+	bus.wake.Signal()
+}
+
+// Delivers whatever is still queued and then shuts the bus down. Must not be called from a subscriber, it would be
+// waiting on itself to finish.
+func (bus *Bus) Close() {
+	bus.mu.Lock()
+	if !bus.closed {
+		bus.closed = true
+		bus.wake.Signal()
+	}
+	bus.mu.Unlock()
+
+	<-bus.done
+}
+
+func (bus *Bus) deliver() {
+	defer close(bus.done)
+	for {
+		bus.mu.Lock()
+		for len(bus.queue) == 0 && !bus.closed {
+			bus.wake.Wait()
+		}
+		if len(bus.queue) == 0 {
+			bus.mu.Unlock()
+			return
+		}
+		event := bus.queue[0]
+		bus.queue = bus.queue[1:]
+		subscriptions := append([]subscription{}, bus.subscriptions...)
+		bus.mu.Unlock()
+
+		for _, subscription := range subscriptions {
+			subscription.subscriber(event)
+		}
+	}
 }
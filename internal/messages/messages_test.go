@@ -0,0 +1,93 @@
+package messages
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/eventtest"
+)
+
+func songNames(events []Event) []string {
+	return eventtest.Collect(events, func(event Event) (string, bool) {
+		return event.SongName, true
+	})
+}
+
+func TestBusDeliversInOrder(t *testing.T) {
+	bus := NewBus()
+	first := &eventtest.Recorder[Event]{}
+	second := &eventtest.Recorder[Event]{}
+	bus.Subscribe(first.Record)
+	bus.Subscribe(second.Record)
+
+	var want []string
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("%d.mp3", i)
+		want = append(want, name)
+		bus.Publish(Event{Kind: TrackStarted, SongName: name})
+	}
+	bus.Close()
+
+	eventtest.AssertEqual(t, songNames(first.Events()), want)
+	eventtest.AssertEqual(t, songNames(second.Events()), want)
+	for _, event := range first.Events() {
+		if event.Time.IsZero() {
+			t.Fatalf("%s was delivered without the time it was published", event.SongName)
+		}
+	}
+}
+
+func TestBusStopsDeliveringOnceUnsubscribed(t *testing.T) {
+	bus := NewBus()
+	stayed := &eventtest.Recorder[Event]{}
+	left := &eventtest.Recorder[Event]{}
+	delivered := make(chan struct{}, 1)
+	bus.Subscribe(stayed.Record)
+	unsubscribe := bus.Subscribe(func(event Event) {
+		left.Record(event)
+		delivered <- struct{}{}
+	})
+
+	// Waits for the first event to get through so unsubscribing can't race it
+	bus.Publish(Event{Kind: TrackStarted, SongName: "a.mp3"})
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("a.mp3 was never delivered")
+	}
+	unsubscribe()
+	unsubscribe() // Doing it again is harmless
+	bus.Publish(Event{Kind: TrackStarted, SongName: "b.mp3"})
+	bus.Close()
+
+	eventtest.AssertEqual(t, songNames(left.Events()), []string{"a.mp3"})
+	eventtest.AssertEqual(t, songNames(stayed.Events()), []string{"a.mp3", "b.mp3"})
+}
+
+func TestBusCloseDeliversWhatIsQueued(t *testing.T) {
+	bus := NewBus()
+	recorder := &eventtest.Recorder[Event]{}
+
+	// A slow subscriber leaves most of the events still queued by the time Close is called
+	bus.Subscribe(func(event Event) {
+		time.Sleep(time.Millisecond)
+		recorder.Record(event)
+	})
+	for i := 0; i < 20; i++ {
+		bus.Publish(Event{Kind: TrackStarted, SongName: fmt.Sprintf("%d.mp3", i)})
+	}
+	bus.Close()
+
+	if got := len(recorder.Events()); got != 20 {
+		t.Fatalf("Close returned with %d of the 20 events delivered", got)
+	}
+
+	// Anything published after closing is dropped rather than delivered
+	bus.Publish(Event{Kind: TrackStarted, SongName: "late.mp3"})
+	bus.Close()
+	if got := len(recorder.Events()); got != 20 {
+		t.Fatalf("%d events delivered after publishing to a closed bus, want 20", got)
+	}
+}
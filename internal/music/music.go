@@ -1,13 +1,13 @@
 package music
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"time"
 
-	// MP3 imports
-	"github.com/hajimehoshi/go-mp3"
-	"github.com/hajimehoshi/oto/v2"
+	// Internal imports
+	"pomogoro/internal/audio"
 
 	// ID3
 	"github.com/bogem/id3v2"
@@ -17,8 +17,9 @@ import (
 type Song struct {
 	Name     string
 	IsPaused bool
-	Player   oto.Player
+	Player   audio.Player
 	Tag      *id3v2.Tag
+	Volume   float64 // Volume to play at between 0 and 1
 }
 
 func (song *Song) Title() string {
@@ -37,40 +38,28 @@ func (song *Song) Genre() string {
 	return song.Tag.Genre()
 }
 
-func (song *Song) SaveDetails(title string, artist string, album string, genre string) {
+func (song *Song) SaveDetails(title string, artist string, album string, genre string) error {
 	song.Tag.SetTitle(title)
 	song.Tag.SetArtist(artist)
 	song.Tag.SetAlbum(album)
 	song.Tag.SetGenre(genre)
 
 	if err := song.Tag.Save(); err != nil {
-		log.Fatal("Error while saving a tag: ", err)
+		return fmt.Errorf("could not save the tags for %s: %w", song.Name, err)
 	}
+	return nil
 }
 
 // TODO(map) Figure out wtf to do with this libraryPath param
-func (song *Song) Play(libraryPath string) {
-	// Open the file that is associated with the currently selected song in the queue
-	f, err := os.Open(libraryPath + "/" + song.Name)
+func (song *Song) Play(output audio.AudioOutput, libraryPath string) error {
+	// Open the file that is associated with the currently selected song in the queue and pick its decoder
+	d, err := audio.OpenFile(libraryPath + "/" + song.Name)
 	if err != nil {
-		log.Println("Err opening file")
-		panic(err)
+		return fmt.Errorf("could not open %s: %w", song.Name, err)
 	}
-	defer f.Close()
+	defer d.Close()
 
-	d, err := mp3.NewDecoder(f)
-	if err != nil {
-		log.Println("Err setting up decoder")
-		panic(err)
-	}
-
-	c, ready, err := oto.NewContext(d.SampleRate(), 2, 2)
-	if err != nil {
-		panic(err)
-	}
-	<-ready
-
-	p := c.NewPlayer(d)
+	p := output.NewPlayer(d)
 	defer p.Close()
 	log.Println("Playing song")
 	p.Play()
@@ -78,8 +67,7 @@ func (song *Song) Play(libraryPath string) {
 	// Assign the player so the controls works
 	song.Player = p
 
-	// TODO(map) Apply volume adjust
-	p.SetVolume(0.2)
+	p.SetVolume(song.Volume)
 
 	for {
 		time.Sleep(time.Second)
@@ -87,6 +75,7 @@ func (song *Song) Play(libraryPath string) {
 			break
 		}
 	}
+	return p.Err()
 }
 
 func (song *Song) Pause() {
@@ -110,16 +99,20 @@ type Library struct {
 	CurrentSong Song
 }
 
-func (library *Library) LoadLibrary(pathToLibrary string) {
+func (library *Library) LoadLibrary(pathToLibrary string) error {
 	songs, err := os.ReadDir(pathToLibrary)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("could not read the library folder: %w", err)
 	}
 
 	library.Songs = []Song{}
 	library.CurrIdx = 0
 
 	for _, song := range songs {
+		if song.IsDir() || !audio.IsSupported(pathToLibrary+"/"+song.Name()) {
+			log.Printf("Skipping unsupported file %s", song.Name())
+			continue
+		}
 		// TODO(map) song.Name() is actually just returning the file name. It makese sense because of how my stuff is named but really we should be reading the ID3 tags here.
 		log.Printf("Adding song %s to queue", song.Name())
 		// TODO(map) Error handling
@@ -131,9 +124,13 @@ func (library *Library) LoadLibrary(pathToLibrary string) {
 	}
 
 	log.Print("Finished loading library...")
+	if len(library.Songs) == 0 {
+		return fmt.Errorf("there are no songs that can be played in %s", pathToLibrary)
+	}
 
 	// Set the current song as the first on
 	library.CurrentSong = library.Songs[library.CurrIdx]
+	return nil
 }
 
 func (library *Library) GetCurrentSong() Song {
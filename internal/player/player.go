@@ -2,71 +2,181 @@ package player
 
 import (
 	"fmt"
-	"time"
+	"log"
+	"sync"
 
 	// Internal imports
+	"pomogoro/internal/audio"
 	"pomogoro/internal/library"
 	"pomogoro/internal/messages"
 	"pomogoro/internal/pomoapp"
+	"pomogoro/internal/song"
 )
 
+// Plays the songs in the library one after another. The play loop, the transition watcher and the controls all run on
+// their own goroutines so IsPlaying, IsPaused, Volume and the current song are only touched while holding mu.
 type Player struct {
-	SongControlChan chan messages.ChannelMessage
-	IsPlaying       bool
-	IsPaused        bool
+	mu        sync.Mutex
+	IsPlaying bool
+	IsPaused  bool
+
+	// Everything that happens to the songs being played is published here for anything that wants to follow along
+	Events *messages.Bus
+
+	// Every song is played through this one output
+	Output audio.AudioOutput
+	Volume float64 // Volume every song is played at between 0 and 1
+
+	currentSong *song.Song
+}
+
+func NewPlayer(output audio.AudioOutput, volume float64) *Player {
+	return &Player{
+		IsPlaying: false,
+		IsPaused:  false,
+		Events:    messages.NewBus(),
+		Output:    output,
+		Volume:    volume,
+	}
+}
+
+// Starts streaming the song in the background
+func (player *Player) PlaySong(song *song.Song) {
+	player.startSong(song, player.volume())
+}
+
+func (player *Player) startSong(song *song.Song, volume float64) {
+	player.setCurrentSong(song)
+	song.SetVolume(volume)
+	go song.Play(player.Output, player.Events)
+}
+
+func (player *Player) setCurrentSong(song *song.Song) {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	player.currentSong = song
+}
+
+func (player *Player) volume() float64 {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.Volume
+}
+
+// Changes the volume of the song playing now and every one after it
+func (player *Player) SetVolume(volume float64) {
+	player.mu.Lock()
+	player.Volume = volume
+	current := player.currentSong
+	player.mu.Unlock()
+
+	if current != nil {
+		current.SetVolume(volume)
+	}
+}
+
+func (player *Player) playing() bool {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	return player.IsPlaying
+}
+
+func (player *Player) setPlaying(playing bool, paused bool) {
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	player.IsPlaying = playing
+	player.IsPaused = paused
+}
+
+// Stops the current song and shuts down the event bus once everything has been delivered
+func (player *Player) Close() {
+	player.mu.Lock()
+	current := player.currentSong
+	player.mu.Unlock()
+
+	if current != nil {
+		current.Stop(false)
+	}
+	player.Events.Close()
 }
 
 func (player *Player) Play(library *library.Library, settings *pomoapp.Settings) {
-	fmt.Println("Initializing channel...")
-	player.SongControlChan = make(chan messages.ChannelMessage)
-	go library.CurrentSong.Play(player.SongControlChan)
-	player.IsPlaying = true
-	for {
-		time.Sleep(time.Second)
-		message, ok := <-player.SongControlChan
-		if !ok {
+	current := library.CurrentSong()
+	if current == nil {
+		log.Println("Nothing in the library to play")
+		return
+	}
+
+	// Follow the songs through the bus for as long as this keeps playing. Once it stops any events still on their way
+	// are dropped rather than left waiting for a loop that is gone.
+	events := make(chan messages.Event)
+	stopped := make(chan struct{})
+	unsubscribe := player.Events.Subscribe(func(event messages.Event) {
+		select {
+		case events <- event:
+		case <-stopped:
+		}
+	})
+	defer func() {
+		unsubscribe()
+		close(stopped)
+	}()
+
+	player.PlaySong(current)
+	player.setPlaying(true, false)
+
+	// Crossfading and gapless playback move on to the next song before this loop would hear that the last one ended
+	transitionsDone := make(chan struct{})
+	go player.watchTransitions(library, settings, transitionsDone)
+	defer close(transitionsDone)
+
+	// Songs that couldn't be played in a row, so a library full of broken files doesn't get cycled through forever
+	failures := 0
+	for event := range events {
+		switch event.Kind {
+		case messages.TrackStarted:
+			failures = 0
+		case messages.TrackFinished, messages.TrackError:
+			if event.Kind == messages.TrackError {
+				log.Printf("Could not play %s: %v", event.SongName, event.Err)
+				failures += 1
+			}
+			if !player.moveOn(library, settings, event.Kind == messages.TrackError) || failures >= len(library.Songs()) {
+				fmt.Println("Stopping player...")
+				library.CurrentSong().Stop(false)
+				player.setPlaying(false, false)
+				return
+			}
+		case messages.TrackStopped:
+			player.setPlaying(false, false)
 			return
-		} else {
-			fmt.Println("Got message = ", message)
-			if message.SongFinished == true {
-				fmt.Println("Song finished message received")
-				if library.HasNextSong && settings.AutoPlay && !settings.Shuffle {
-					library.IncIndex()
-					fmt.Println("Starting next song...")
-					go library.CurrentSong.Play(player.SongControlChan)
-					fmt.Println("Started next song...")
-				} else if library.HasNextSong && settings.AutoPlay && settings.Shuffle {
-					library.NextShuffle()
-					fmt.Println("Starting next song...")
-					go library.CurrentSong.Play(player.SongControlChan)
-					fmt.Println("Started next song...")
-				} else {
-					fmt.Println("Stopping player...")
-					library.CurrentSong.Stop(false)
-					break
-				}
-			} else if message.SongStopped == true {
-				fmt.Println("Song stopped message received")
-				break
-			} else if message.SongPaused == true {
-				fmt.Println("Song paused message received")
-				player.IsPlaying = false
-				player.IsPaused = true
-			} else if message.SongResumed == true {
-				fmt.Println("Song resumed message received")
-				player.IsPlaying = true
-				player.IsPaused = false
-			} else if message.SongSkipped == true {
-				fmt.Println("Song skipped message received")
-				// Start playing the next song if the stage is not paused
-				if player.IsPlaying {
-					fmt.Println("Playing next song")
-					go library.CurrentSong.Play(player.SongControlChan)
-				}
+		case messages.TrackPaused:
+			player.setPlaying(false, true)
+		case messages.TrackResumed:
+			player.setPlaying(true, false)
+		case messages.TrackSkipped:
+			// Start playing the next song if the stage is not paused
+			if player.playing() {
+				player.PlaySong(library.CurrentSong())
 			}
 		}
 	}
-	fmt.Println("Closing channel...")
-	close(player.SongControlChan)
-	fmt.Println("Closed...")
+}
+
+// Starts the next song from the queue, or from the library when autoplaying, unless the song is being repeated. A song
+// that failed is never repeated since it would only fail again. Returns false if there is nothing left to play.
+func (player *Player) moveOn(library *library.Library, settings *pomoapp.Settings, failed bool) bool {
+	playback := settings.Playback()
+	if playback.Repeat == pomoapp.RepeatOne && !failed {
+		player.PlaySong(library.CurrentSong())
+		return true
+	}
+	if library.Queue.Len() == 0 && !playback.AutoPlay {
+		return false
+	}
+	if !library.Next(settings) {
+		return false
+	}
+	player.PlaySong(library.CurrentSong())
+	return true
 }
@@ -0,0 +1,185 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+	"pomogoro/internal/audio/audiotest"
+	"pomogoro/internal/eventtest"
+	"pomogoro/internal/library"
+	"pomogoro/internal/messages"
+	"pomogoro/internal/pomoapp"
+)
+
+const songLength = 300 * time.Millisecond // Long enough for the transitions to notice the end of each test song coming
+
+// Library loaded from a folder of short songs named after what is given, in the same order
+func newTestLibrary(t *testing.T, settings *pomoapp.Settings, names ...string) *library.Library {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		audiotest.WriteWAV(t, filepath.Join(dir, name), songLength)
+	}
+	settings.LibraryRoots = []string{dir}
+
+	testLibrary := &library.Library{}
+	if err := testLibrary.LoadLibrary(settings); err != nil {
+		t.Fatal(err)
+	}
+	return testLibrary
+}
+
+// Records every playback event the player publishes
+func recordEvents(player *Player) *eventtest.Recorder[messages.Event] {
+	recorder := &eventtest.Recorder[messages.Event]{}
+	player.Events.Subscribe(recorder.Record)
+	return recorder
+}
+
+// Names of the songs that were started, in the order they were
+func started(events []messages.Event) []string {
+	return eventtest.Collect(events, func(event messages.Event) (string, bool) {
+		return event.SongName, event.Kind == messages.TrackStarted
+	})
+}
+
+// Runs the player until it stops by itself
+func playUntilStopped(t *testing.T, player *Player, testLibrary *library.Library, settings *pomoapp.Settings) {
+	t.Helper()
+	stopped := make(chan struct{})
+	go func() {
+		player.Play(testLibrary, settings)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("player did not stop")
+	}
+	player.Close()
+}
+
+func TestPlayerPlaysOneSongWithoutAutoplay(t *testing.T) {
+	settings := &pomoapp.Settings{}
+	testLibrary := newTestLibrary(t, settings, "a.wav", "b.wav")
+	output := audio.NewMemoryOutput(audio.DefaultSampleRate)
+	player := NewPlayer(output, 1)
+	recorder := recordEvents(player)
+
+	playUntilStopped(t, player, testLibrary, settings)
+
+	eventtest.AssertEqual(t, started(recorder.Events()), []string{"a.wav"})
+	players := output.Players()
+	if len(players) != 1 {
+		t.Fatalf("got %d players on the output, want 1", len(players))
+	}
+	if played := len(players[0].Played()); played != int(songLength*audio.DefaultSampleRate/time.Second)*audio.FrameSize {
+		t.Fatalf("played %d bytes, want the whole song", played)
+	}
+}
+
+func TestPlayerAutoplaysThroughTheLibrary(t *testing.T) {
+	settings := &pomoapp.Settings{AutoPlay: true}
+	testLibrary := newTestLibrary(t, settings, "a.wav", "b.wav", "c.wav")
+	output := audio.NewMemoryOutput(audio.DefaultSampleRate)
+	player := NewPlayer(output, 1)
+	recorder := recordEvents(player)
+
+	playUntilStopped(t, player, testLibrary, settings)
+
+	// Every song goes through the one output
+	eventtest.AssertEqual(t, started(recorder.Events()), []string{"a.wav", "b.wav", "c.wav"})
+	if len(output.Players()) != 3 {
+		t.Fatalf("got %d players on the output, want 3", len(output.Players()))
+	}
+}
+
+func TestPlayerGaplessCarriesOnInTheSamePlayer(t *testing.T) {
+	settings := &pomoapp.Settings{AutoPlay: true, Gapless: true}
+	testLibrary := newTestLibrary(t, settings, "a.wav", "b.wav", "c.wav")
+	// Played in real time so the transitions have a chance to line the next song up
+	output := audio.NewNullOutput(audio.DefaultSampleRate)
+	player := NewPlayer(output, 1)
+	recorder := recordEvents(player)
+
+	playUntilStopped(t, player, testLibrary, settings)
+
+	eventtest.AssertEqual(t, started(recorder.Events()), []string{"a.wav", "b.wav", "c.wav"})
+	if len(output.Players()) != 1 {
+		t.Fatalf("got %d players on the output, want every song read through the one", len(output.Players()))
+	}
+	if testLibrary.CurrentSong().Name != "c.wav" {
+		t.Fatalf("library ended on %s, want c.wav", testLibrary.CurrentSong().Name)
+	}
+}
+
+func TestPlayerCrossfadesIntoTheNextSong(t *testing.T) {
+	settings := &pomoapp.Settings{AutoPlay: true, CrossfadeSeconds: 1}
+	testLibrary := newTestLibrary(t, settings, "a.wav", "b.wav")
+	output := audio.NewNullOutput(audio.DefaultSampleRate)
+	player := NewPlayer(output, 1)
+	recorder := recordEvents(player)
+
+	playUntilStopped(t, player, testLibrary, settings)
+
+	eventtest.AssertEqual(t, started(recorder.Events()), []string{"a.wav", "b.wav"})
+	if len(output.Players()) != 2 {
+		t.Fatalf("got %d players on the output, want one for each song", len(output.Players()))
+	}
+}
+
+func TestPlayerRepeatOneSkipsPastBrokenSong(t *testing.T) {
+	settings := &pomoapp.Settings{AutoPlay: true, Repeat: pomoapp.RepeatOne}
+	testLibrary := newTestLibrary(t, settings, "a.wav", "b.wav")
+	// Break the first song after it has been found so it fails once it is played
+	if err := os.WriteFile(testLibrary.Songs()[0].FilePath, []byte("RIFF\x00\x00\x00\x00WAVEjunk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := audio.NewMemoryOutput(audio.DefaultSampleRate)
+	player := NewPlayer(output, 1)
+	recorder := recordEvents(player)
+
+	stopped := make(chan struct{})
+	go func() {
+		player.Play(testLibrary, settings)
+		close(stopped)
+	}()
+	// The working song repeats forever so wait for it to come around a few times
+	deadline := time.Now().Add(5 * time.Second)
+	for len(started(recorder.Events())) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("the song after the broken one was not repeated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// A stop can land just before the repeat starts the song again so keep at it until the player notices
+	for stopping := true; stopping; {
+		testLibrary.CurrentSong().Stop(false)
+		select {
+		case <-stopped:
+			stopping = false
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	player.Close()
+
+	events := recorder.Events()
+	errors := 0
+	for _, event := range events {
+		if event.Kind == messages.TrackError {
+			errors += 1
+		}
+	}
+	if errors != 1 {
+		t.Fatalf("broken song failed %d times, want it tried once", errors)
+	}
+	for _, name := range started(events) {
+		if name != "b.wav" {
+			t.Fatalf("started %s, want only b.wav to be repeated", name)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package player
+
+import (
+	"log"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/library"
+	"pomogoro/internal/pomoapp"
+	"pomogoro/internal/song"
+)
+
+const (
+	transitionInterval = 50 * time.Millisecond // How often the end of the song is checked for and the fade stepped
+	preloadLead        = 5 * time.Second       // How long before a transition the next song is opened and decoded
+)
+
+// Watches the current song while autoplaying with crossfading or gapless playback turned on. The next song is loaded
+// ahead of time and started by this rather than waiting for the current one to report that it finished.
+//
+// Crossfading plays the next song on a player of its own alongside the current one. Gapless playback instead chains
+// the next song's stream on to the end of the current one's in the same player, so the output reads straight from one
+// into the other without anything having to be timed.
+func (player *Player) watchTransitions(library *library.Library, settings *pomoapp.Settings, done chan struct{}) {
+	ticker := time.NewTicker(transitionInterval)
+	defer ticker.Stop()
+
+	// The next song once it has been loaded for crossfading, let go of again if plans change before it gets played
+	var preloaded *song.Song
+	var failed *song.Song
+	release := func() {
+		if preloaded != nil {
+			preloaded.Unload()
+			preloaded = nil
+		}
+	}
+	defer release()
+
+	// The next song once it has been chained on to the end of the current one for gapless playback
+	var chained *song.Song
+	var chainedFrom *song.Song
+	var switched <-chan struct{}
+	unchain := func() {
+		// Once the current song has run out into the chained one it can't be taken back, it gets followed on the
+		// next tick instead
+		if chained != nil && chainedFrom.Unchain() {
+			chained.Unload()
+			chained = nil
+		}
+	}
+	defer unchain()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if chained != nil {
+			select {
+			case <-switched:
+				player.carryOn(library, chainedFrom, chained)
+				chained = nil
+			default:
+			}
+		}
+
+		playback := settings.Playback()
+		crossfade := playback.Crossfade
+		gapless := crossfade == 0 && playback.Gapless
+		if !playback.AutoPlay || (crossfade == 0 && !gapless) || !player.playing() {
+			release()
+			unchain()
+			continue
+		}
+
+		current := library.CurrentSong()
+		if current == nil {
+			continue
+		}
+		duration := current.Duration()
+		if !current.IsPlaying() || duration == 0 {
+			continue
+		}
+		remaining := duration - current.Position()
+		if remaining > crossfade+preloadLead {
+			continue
+		}
+
+		// NOTE(map) Repeating one song goes through the player starting it over rather than handing off to itself
+		next, ok := library.PeekNextSong(settings)
+		if !ok || playback.Repeat == pomoapp.RepeatOne || next == current {
+			release()
+			unchain()
+			continue
+		}
+
+		if gapless {
+			release()
+			if chained != nil && (chained != next || chainedFrom != current) {
+				unchain()
+			}
+			if chained != nil || next == failed {
+				continue
+			}
+			ran, err := next.ChainAfter(current)
+			if err != nil {
+				log.Printf("Could not line up %s to play next: %v", next.Name, err)
+				failed = next
+				continue
+			}
+			chained, chainedFrom, switched = next, current, ran
+			continue
+		}
+
+		unchain()
+		if preloaded != next {
+			release()
+			if next == failed {
+				// Already couldn't be loaded, the player will deal with it once the current song ends
+				continue
+			}
+			if err := next.Load(player.Output); err != nil {
+				log.Printf("Could not load %s ahead of time: %v", next.Name, err)
+				failed = next
+				continue
+			}
+			preloaded = next
+		}
+
+		if remaining <= crossfade {
+			player.handOff(library, settings, remaining)
+			if library.CurrentSong() == preloaded {
+				preloaded = nil
+			} else {
+				// The queue changed at the last moment so a different song was started
+				release()
+			}
+		}
+	}
+}
+
+// Follows the player on into the song that was chained on to the end of the previous one. It is already being heard
+// so the library moves to it even if the queue changed in the moment since it was lined up.
+func (player *Player) carryOn(library *library.Library, previous *song.Song, next *song.Song) {
+	library.MoveTo(next)
+	player.setCurrentSong(next)
+	next.SetVolume(player.volume())
+	go next.PlayOnFrom(previous, player.Events)
+}
+
+// Moves the library or queue on to the next song and fades it in over the current one
+func (player *Player) handOff(library *library.Library, settings *pomoapp.Settings, fade time.Duration) {
+	previous := library.CurrentSong()
+	previous.HandOff()
+	library.Next(settings)
+
+	next := library.CurrentSong()
+	player.startSong(next, 0)
+	go player.crossfade(previous, next, fade)
+}
+
+// Fades one song out and the other in together, following any change to the volume while it happens
+func (player *Player) crossfade(from *song.Song, to *song.Song, fade time.Duration) {
+	ticker := time.NewTicker(transitionInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for range ticker.C {
+		progress := float64(time.Since(start)) / float64(fade)
+		if progress >= 1 {
+			break
+		}
+		volume := player.volume()
+		from.SetVolume(volume * (1 - progress))
+		to.SetVolume(volume * progress)
+	}
+	from.Stop(false)
+	to.SetVolume(player.volume())
+}
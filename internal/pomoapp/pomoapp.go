@@ -3,15 +3,94 @@ package pomoapp
 import (
 	"encoding/json"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 )
 
+const (
+	appDirName = "pomogoro"
+
+	DefaultVolume = 0.1 // Volume the music starts at before it has ever been changed
+	VolumeStep    = 0.1 // How much the keyboard shortcuts move the volume by
+
+	MaxCrossfadeSeconds = 12 // Longest the end of one song can be faded into the next
+)
+
+// What happens once the end of a song or the library is reached
+type RepeatMode string
+
+const (
+	RepeatOff RepeatMode = ""    // Stop at the end of the library
+	RepeatAll RepeatMode = "all" // Go back to the start of the library once it runs out
+	RepeatOne RepeatMode = "one" // Keep playing the same song
+)
+
+// The mode after this one when cycling through them with the toggle
+func (mode RepeatMode) Next() RepeatMode {
+	switch mode {
+	case RepeatOff:
+		return RepeatAll
+	case RepeatAll:
+		return RepeatOne
+	default:
+		return RepeatOff
+	}
+}
+
+func (mode RepeatMode) String() string {
+	switch mode {
+	case RepeatAll:
+		return "All"
+	case RepeatOne:
+		return "One"
+	default:
+		return "Off"
+	}
+}
+
+// Settings changed from the GUI and read by the player goroutines as songs play out. The fields are exported so they
+// can be written out, but once the players are running they should only be reached through the methods, which hold mu.
 type Settings struct {
+	mu sync.Mutex
+
 	SettingsPath string
-	LibraryPath  string
+	LibraryRoots []string // Folders searched for music, including everything under them
+	LibraryPath  string   // Single library folder from before there could be several, used when there are no roots
 	AutoPlay     bool
 	Shuffle      bool
 	LinkPlayers  bool
+
+	Volume float64 // Music volume between 0 and 1, kept while muted so unmuting goes back to it
+	Muted  bool
+
+	// Transitions between songs when autoplaying. Crossfading takes priority over gapless when both are set.
+	CrossfadeSeconds int  // How long to fade one song into the next, 0 to not crossfade
+	Gapless          bool // Start the next song the moment the last one ends with no silence in between
+
+	Repeat RepeatMode
+
+	// Glob patterns picking which files under the library roots are included, such as "*.flac" or "Podcasts/*"
+	IncludePatterns []string // Only files matching one of these are included, or every file when empty
+	ExcludePatterns []string // Files and folders matching any of these are left out
+}
+
+// How songs play on from one to the next, copied out of the settings so they can be read together without the lock
+type Playback struct {
+	AutoPlay  bool
+	Shuffle   bool
+	Gapless   bool
+	Crossfade time.Duration // How long to fade one song into the next, 0 to not crossfade
+	Repeat    RepeatMode
+}
+
+// Where the library is loaded from, copied out of the settings so a scan can't see them change part way through
+type LibraryFolders struct {
+	Roots           []string
+	IncludePatterns []string
+	ExcludePatterns []string
 }
 
 func NewSettings(
@@ -27,26 +106,133 @@ func NewSettings(
 		AutoPlay:     autoPlay,
 		Shuffle:      shuffle,
 		LinkPlayers:  linkPlayers,
+		Volume:       DefaultVolume,
 	}
 }
 
 func (settings *Settings) Save(
-	libraryPath string,
+	libraryRoots []string,
+	includePatterns []string,
+	excludePatterns []string,
 	autoPlayChecked bool,
 	shuffleChecked bool,
 	linkPlayersChecked bool,
+	crossfadeSeconds int,
+	gaplessChecked bool,
 ) {
-	settings.LibraryPath = libraryPath
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	settings.LibraryRoots = libraryRoots
+	settings.LibraryPath = ""
+	settings.IncludePatterns = includePatterns
+	settings.ExcludePatterns = excludePatterns
 	settings.AutoPlay = autoPlayChecked
 	settings.Shuffle = shuffleChecked
 	settings.LinkPlayers = linkPlayersChecked
+	settings.CrossfadeSeconds = crossfadeSeconds
+	settings.Gapless = gaplessChecked
+	settings.writeLocked()
+}
 
+func (settings *Settings) Playback() Playback {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	return Playback{
+		AutoPlay:  settings.AutoPlay,
+		Shuffle:   settings.Shuffle,
+		Gapless:   settings.Gapless,
+		Crossfade: time.Duration(settings.CrossfadeSeconds) * time.Second,
+		Repeat:    settings.Repeat,
+	}
+}
+
+// Folders the library is loaded from, falling back to the single library path from older settings
+func (settings *Settings) Roots() []string {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	return settings.rootsLocked()
+}
+
+func (settings *Settings) rootsLocked() []string {
+	if len(settings.LibraryRoots) > 0 {
+		return append([]string{}, settings.LibraryRoots...)
+	}
+	if settings.LibraryPath != "" {
+		return []string{settings.LibraryPath}
+	}
+	return nil
+}
+
+func (settings *Settings) LibraryFolders() LibraryFolders {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	return LibraryFolders{
+		Roots:           settings.rootsLocked(),
+		IncludePatterns: append([]string{}, settings.IncludePatterns...),
+		ExcludePatterns: append([]string{}, settings.ExcludePatterns...),
+	}
+}
+
+// Stores the volume, clamped between 0 and 1
+func (settings *Settings) SaveVolume(volume float64) {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	settings.Volume = math.Max(0, math.Min(1, volume))
+	settings.writeLocked()
+}
+
+// Changes the volume and unmutes without writing it out, for while the volume is still being dragged
+func (settings *Settings) SetVolume(volume float64) {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	settings.Volume = math.Max(0, math.Min(1, volume))
+	settings.Muted = false
+}
+
+// The volume kept while muted, between 0 and 1
+func (settings *Settings) VolumeLevel() float64 {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	return settings.Volume
+}
+
+// Mutes or unmutes and stores it. Returns whether it is now muted.
+func (settings *Settings) ToggleMuted() bool {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	settings.Muted = !settings.Muted
+	settings.writeLocked()
+	return settings.Muted
+}
+
+// Moves on to the next repeat mode and stores it. Returns the new mode.
+func (settings *Settings) CycleRepeat() RepeatMode {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	settings.Repeat = settings.Repeat.Next()
+	settings.writeLocked()
+	return settings.Repeat
+}
+
+// The volume the music should actually be played at once muting is taken into account
+func (settings *Settings) EffectiveVolume() float64 {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
+	if settings.Muted {
+		return 0
+	}
+	return settings.Volume
+}
+
+func (settings *Settings) writeLocked() {
 	// TODO(map) Handle errors gracefully
 	file, _ := json.MarshalIndent(settings, "", "    ")
 	_ = os.WriteFile(settings.SettingsPath, file, 0644)
 }
 
 func (settings *Settings) Load() {
+	settings.mu.Lock()
+	defer settings.mu.Unlock()
 	// func loadSettings(pathToSettings string) {
 	settingsFile, _ := os.ReadFile(settings.SettingsPath)
 	err := json.Unmarshal(settingsFile, &settings)
@@ -54,3 +240,17 @@ func (settings *Settings) Load() {
 		log.Print("Failure in unmarshelling the settings data")
 	}
 }
+
+// Returns the directory the application keeps its data in, creating it if it doesn't exist yet
+func DataDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	dataDir := filepath.Join(configDir, appDirName)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return "", err
+	}
+	return dataDir, nil
+}
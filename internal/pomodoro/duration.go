@@ -0,0 +1,88 @@
+package pomodoro
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxPhaseTime  = 24 * 60 * 60 // Longest a single focus or break can be in seconds
+	MaxIterations = 100          // Most Focus/Relax iterations a single session can have
+)
+
+// Parses the time for a phase into seconds. A bare number is taken as minutes, "mm:ss" as minutes and seconds, and
+// anything else as a Go duration string such as "25m", "1h30m" or "90s".
+func ParseDuration(text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, errors.New("enter a time such as 25, 25m, 1h30m or 90s")
+	}
+
+	var duration time.Duration
+	if minutes, err := strconv.Atoi(text); err == nil {
+		duration = time.Duration(minutes) * time.Minute
+	} else if minutesText, secondsText, found := strings.Cut(text, ":"); found {
+		minutes, minutesErr := strconv.Atoi(minutesText)
+		seconds, secondsErr := strconv.Atoi(secondsText)
+		if minutesErr != nil || secondsErr != nil || seconds < 0 || seconds >= 60 {
+			return 0, fmt.Errorf("%q is not a valid mm:ss time", text)
+		}
+		duration = time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	} else {
+		duration, err = time.ParseDuration(text)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid time, try something like 25m, 1h30m or 90s", text)
+		}
+	}
+
+	if duration%time.Second != 0 {
+		return 0, errors.New("time must be a whole number of seconds")
+	}
+	seconds := int(duration / time.Second)
+	if seconds <= 0 {
+		return 0, errors.New("time must be greater than zero")
+	}
+	if seconds > MaxPhaseTime {
+		return 0, errors.New("time cannot be longer than 24 hours")
+	}
+	return seconds, nil
+}
+
+// Formats seconds the same way ParseDuration reads them, dropping any zero units so 1500 becomes "25m"
+func FormatDuration(seconds int) string {
+	formatted := (time.Duration(seconds) * time.Second).String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+	return formatted
+}
+
+// Parses a count of iterations, which has to be between 1 and MaxIterations
+func ParseIterations(text string) (int, error) {
+	iterations, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return 0, errors.New("enter a whole number of iterations")
+	}
+	if iterations < 1 || iterations > MaxIterations {
+		return 0, fmt.Errorf("iterations must be between 1 and %d", MaxIterations)
+	}
+	return iterations, nil
+}
+
+// Parses how many focus periods to complete between long breaks, where 0 turns long breaks off
+func ParseLongBreakInterval(text string) (int, error) {
+	interval, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return 0, errors.New("enter a whole number of focus periods")
+	}
+	if interval < 0 || interval > MaxIterations {
+		return 0, fmt.Errorf("interval must be between 0 and %d", MaxIterations)
+	}
+	return interval, nil
+}
@@ -0,0 +1,113 @@
+package pomodoro
+
+import "testing"
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		text    string
+		seconds int
+		valid   bool
+	}{
+		{"25", 1500, true},
+		{" 25 ", 1500, true},
+		{"25:00", 1500, true},
+		{"1:30", 90, true},
+		{"0:59", 59, true},
+		{"25m", 1500, true},
+		{"1h30m", 5400, true},
+		{"90s", 90, true},
+		{"24h", MaxPhaseTime, true},
+		{"1440", MaxPhaseTime, true},
+		{"", 0, false},
+		{"0", 0, false},
+		{"0:00", 0, false},
+		{"0s", 0, false},
+		{"-5", 0, false},
+		{"-5m", 0, false},
+		{"1:60", 0, false},
+		{"1:-1", 0, false},
+		{"a:30", 0, false},
+		{"1.5s", 0, false},
+		{"24h1s", 0, false},
+		{"1441", 0, false},
+		{"soon", 0, false},
+	}
+	for _, test := range tests {
+		seconds, err := ParseDuration(test.text)
+		if valid := err == nil; valid != test.valid || seconds != test.seconds {
+			t.Errorf("ParseDuration(%q) = %d, %v, want %d and valid %v", test.text, seconds, err, test.seconds, test.valid)
+		}
+	}
+}
+
+func TestFormatDurationReadsBack(t *testing.T) {
+	tests := []struct {
+		seconds int
+		text    string
+	}{
+		{1500, "25m"},
+		{90, "1m30s"},
+		{45, "45s"},
+		{3600, "1h"},
+		{5400, "1h30m"},
+		{3661, "1h1m1s"},
+		{MaxPhaseTime, "24h"},
+	}
+	for _, test := range tests {
+		text := FormatDuration(test.seconds)
+		if text != test.text {
+			t.Errorf("FormatDuration(%d) = %q, want %q", test.seconds, text, test.text)
+		}
+		if seconds, err := ParseDuration(text); err != nil || seconds != test.seconds {
+			t.Errorf("ParseDuration(%q) = %d, %v, want %d back", text, seconds, err, test.seconds)
+		}
+	}
+}
+
+func TestParseIterations(t *testing.T) {
+	tests := []struct {
+		text       string
+		iterations int
+		valid      bool
+	}{
+		{"1", 1, true},
+		{" 4 ", 4, true},
+		{"100", MaxIterations, true},
+		{"0", 0, false},
+		{"-1", 0, false},
+		{"101", 0, false},
+		{"", 0, false},
+		{"four", 0, false},
+		{"2.5", 0, false},
+	}
+	for _, test := range tests {
+		iterations, err := ParseIterations(test.text)
+		if valid := err == nil; valid != test.valid || iterations != test.iterations {
+			t.Errorf("ParseIterations(%q) = %d, %v, want %d and valid %v",
+				test.text, iterations, err, test.iterations, test.valid)
+		}
+	}
+}
+
+func TestParseLongBreakInterval(t *testing.T) {
+	tests := []struct {
+		text     string
+		interval int
+		valid    bool
+	}{
+		{"0", 0, true},
+		{"4", 4, true},
+		{"100", MaxIterations, true},
+		{"-1", 0, false},
+		{"101", 0, false},
+		{"", 0, false},
+		{"never", 0, false},
+	}
+	for _, test := range tests {
+		interval, err := ParseLongBreakInterval(test.text)
+		if valid := err == nil; valid != test.valid || interval != test.interval {
+			t.Errorf("ParseLongBreakInterval(%q) = %d, %v, want %d and valid %v",
+				test.text, interval, err, test.interval, test.valid)
+		}
+	}
+}
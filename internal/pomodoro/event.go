@@ -0,0 +1,52 @@
+package pomodoro
+
+import (
+	"time"
+)
+
+// Something that happened to the timer, as opposed to State which is only what it looks like right now
+type EventKind int
+
+const (
+	PhaseStarted EventKind = iota
+	PhaseEnded
+	PhaseSkipped
+	TimerPaused
+	TimerResumed
+	TimerReset
+	SessionCompleted
+)
+
+func (kind EventKind) String() string {
+	switch kind {
+	case PhaseStarted:
+		return "phase_started"
+	case PhaseEnded:
+		return "phase_ended"
+	case PhaseSkipped:
+		return "phase_skipped"
+	case TimerPaused:
+		return "paused"
+	case TimerResumed:
+		return "resumed"
+	case TimerReset:
+		return "reset"
+	case SessionCompleted:
+		return "session_completed"
+	default:
+		return "unknown"
+	}
+}
+
+type Event struct {
+	Kind           EventKind
+	Time           time.Time
+	Phase          Phase  // The phase the event happened in, for ends and skips this is the phase that finished
+	CurrentTimer   int    // Time that was left in the phase in seconds
+	IterationCount int    // The number of Focus/Relax iterations completed
+	Iterations     int    // The total number of iterations to complete
+	PresetName     string // Name of the preset the timer is running, empty if it was entered by hand
+}
+
+// Callback that is notified of every Event as it happens
+type EventSubscriber func(event Event)
@@ -1,260 +1,492 @@
 package pomodoro
 
 import (
-	"fmt"
-	"image/color"
-	"pomogoro/internal/library"
-	"pomogoro/internal/pomoapp"
+	"sync"
 	"time"
 
-	// Gui imports
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/widget"
+	// Internal imports
+	"pomogoro/internal/clock"
 )
 
+// The portion of the Pomodoro the timer is currently counting down
+type Phase int
+
+const (
+	FocusPhase Phase = iota
+	RelaxPhase
+	LongBreakPhase
+	CompletePhase // Every iteration has been completed so there is nothing left to count down
+)
+
+func (phase Phase) String() string {
+	switch phase {
+	case RelaxPhase:
+		return "Relax"
+	case LongBreakPhase:
+		return "Long Break"
+	case CompletePhase:
+		return "Session Complete"
+	default:
+		return "Focus"
+	}
+}
+
 // Structure to represent a Pomodoro instance
 type PomodoroSettings struct {
 	StartFocusTime int // Start time for focus in seconds
 	StartRelaxTime int // Starting point for the relax timer in seconds
 	Iterations     int // Number of times the Focus/Relax combination should be repeated
-	IterationCount int // The current count of the number of iterations completed
+
+	StartLongBreakTime int // Starting point for the long break timer in seconds
+	LongBreakInterval  int // Take a long break instead of a relax after this many focus periods, 0 disables it
+
+	CountFinalBreak bool // Whether to count down the break after the last focus period before completing
 
 	// TODO(map) I will need to include something like focus playist and relaxing playlist or something like that
 	// eventually. For now I can just do pause music during break
 	PauseDuringBreak bool
 }
 
-// All things related to the Pomodoro including canvas to draw timer, the settings, and current status
+// Snapshot of the timer that is handed to subscribers every time something changes
+type State struct {
+	Phase          Phase  // The portion of the Pomodoro being counted down
+	CurrentTimer   int    // Time left in the current phase in seconds
+	IterationCount int    // The number of Focus/Relax iterations completed
+	Iterations     int    // The total number of iterations to complete
+	IsRunning      bool   // Flag for if the timer is counting down
+	PresetName     string // Name of the preset the settings came from, empty if they were entered by hand
+
+	Remaining   time.Duration // Exact time left in the current phase for anything that needs finer detail than seconds
+	PhaseLength time.Duration // How long the current phase is in total
+}
+
+// Callback that is notified with the latest State of the timer
+type Subscriber func(state State)
+
+// Callback that is notified once when every iteration of the session has been completed
+type CompletionSubscriber func(state State)
+
+// The headless Pomodoro engine. It only tracks the countdown and publishes changes, leaving rendering to whatever
+// frontend has subscribed to it.
+//
+// The timer is safe to drive from any goroutine. All state is guarded by mu and read from outside through State.
+// Subscribers are called without mu held, one notification at a time and in the order the changes happened, but they
+// must not call back into methods that change the timer or they will deadlock waiting on themselves.
 type PomodoroTimer struct {
-	CurrentTimer int  // The current time on the timer in seconds
-	IsRunning    bool // Flag for if the timer is running
-	InBreakMode  bool // Flag for whether we are in the relax portion or focus portion of the timer
+	mu       sync.Mutex
+	notifyMu sync.Mutex // Serializes notifications so subscribers never see changes out of order
+
+	currentTimer   int   // The current time on the timer in seconds
+	isRunning      bool  // Flag for if the timer is running
+	phase          Phase // Whether we are in the relax portion or focus portion of the timer
+	iterationCount int   // The current count of the number of iterations completed
+
+	settings   PomodoroSettings // The settings of the particular timer
+	presetName string           // Name of the preset the settings came from, empty if they were entered by hand
+
+	// NOTE(map) The countdown is measured against a deadline rather than by subtracting a second after every sleep so
+	// scheduler latency doesn't accumulate and slow the timer down over a long session.
+	clock     clock.Clock
+	remaining time.Duration // Exact time left in the current phase, currentTimer is this rounded up to the second
+	deadline  time.Time     // When the current phase ends while the timer is running
+
+	// Each countdown loop is handed the stop channel that was current when it started. Closing it on pause means a
+	// loop that is still waking up from its last tick exits instead of running alongside the next one.
+	stop chan struct{}
+	tick clock.Timer // What the countdown loop is sleeping on, stopped along with the loop
+
+	phaseStarted bool // Whether the current phase has been started yet, used to tell a start from a resume
+
+	// Notifications that have happened but not been delivered yet
+	pendingEvents     []Event
+	pendingCompletion bool
+
+	subscribers           []Subscriber
+	completionSubscribers []CompletionSubscriber
+	eventSubscribers      []EventSubscriber
+}
+
+func NewPomodoroTimer(clock clock.Clock) *PomodoroTimer {
+	return &PomodoroTimer{
+		isRunning: false,
+		phase:     FocusPhase,
+		clock:     clock,
+	}
+}
 
-	PomodoroSettings    PomodoroSettings    // The settings of the particular timer
-	PomodoroTimerCanvas PomodoroTimerCanvas // Canvas to draw the timer and access all components
+// Registers a subscriber and immediately sends it the current state so it can render right away
+func (pt *PomodoroTimer) Subscribe(subscriber Subscriber) {
+	pt.notifyMu.Lock()
+	defer pt.notifyMu.Unlock()
+
+	pt.mu.Lock()
+	pt.subscribers = append(pt.subscribers, subscriber)
+	state := pt.stateLocked()
+	pt.mu.Unlock()
+
+	subscriber(state)
+}
+
+// Registers a subscriber that is only notified when the session is completed
+func (pt *PomodoroTimer) SubscribeSessionComplete(subscriber CompletionSubscriber) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.completionSubscribers = append(pt.completionSubscribers, subscriber)
+}
+
+// Registers a subscriber that is notified of every start, end, pause, resume, reset and skip
+func (pt *PomodoroTimer) SubscribeEvents(subscriber EventSubscriber) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.eventSubscribers = append(pt.eventSubscribers, subscriber)
+}
+
+func (pt *PomodoroTimer) State() State {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.stateLocked()
+}
+
+func (pt *PomodoroTimer) stateLocked() State {
+	return State{
+		Phase:          pt.phase,
+		CurrentTimer:   pt.currentTimer,
+		IterationCount: pt.iterationCount,
+		Iterations:     pt.settings.Iterations,
+		IsRunning:      pt.isRunning,
+		PresetName:     pt.presetName,
+		Remaining:      pt.remaining,
+		PhaseLength:    pt.phaseLengthLocked(),
+	}
+}
+
+func (pt *PomodoroTimer) Settings() PomodoroSettings {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.settings
 }
 
-func NewPomodoroTimer(library *library.Library, settings *pomoapp.Settings) *PomodoroTimer {
-	pt := &PomodoroTimer{
-		IsRunning:   false,
-		InBreakMode: false,
+func (pt *PomodoroTimer) phaseLengthLocked() time.Duration {
+	switch pt.phase {
+	case FocusPhase:
+		return time.Duration(pt.settings.StartFocusTime) * time.Second
+	case RelaxPhase:
+		return time.Duration(pt.settings.StartRelaxTime) * time.Second
+	case LongBreakPhase:
+		return time.Duration(pt.settings.StartLongBreakTime) * time.Second
+	default:
+		return 0
 	}
+}
+
+// Queues up an event to be delivered by the next publish
+func (pt *PomodoroTimer) emitLocked(kind EventKind) {
+	pt.pendingEvents = append(pt.pendingEvents, Event{
+		Kind:           kind,
+		Time:           pt.clock.Now(),
+		Phase:          pt.phase,
+		CurrentTimer:   pt.currentTimer,
+		IterationCount: pt.iterationCount,
+		Iterations:     pt.settings.Iterations,
+		PresetName:     pt.presetName,
+	})
+}
+
+// Delivers any queued events followed by the latest state. Must be called without mu held.
+func (pt *PomodoroTimer) publish() {
+	pt.notifyMu.Lock()
+	defer pt.notifyMu.Unlock()
 
-	pt.CreateDefaultCanvas(library, settings)
+	pt.mu.Lock()
+	events := pt.pendingEvents
+	completed := pt.pendingCompletion
+	pt.pendingEvents = nil
+	pt.pendingCompletion = false
+	state := pt.stateLocked()
+	subscribers := pt.subscribers
+	eventSubscribers := pt.eventSubscribers
+	completionSubscribers := pt.completionSubscribers
+	pt.mu.Unlock()
 
-	return pt
+	for _, event := range events {
+		for _, subscriber := range eventSubscribers {
+			subscriber(event)
+		}
+	}
+	for _, subscriber := range subscribers {
+		subscriber(state)
+	}
+	if completed {
+		for _, subscriber := range completionSubscribers {
+			subscriber(state)
+		}
+	}
 }
 
+// Starts counting down in the background. Does nothing if the timer is already running, so at most one countdown loop
+// ever exists.
 func (pt *PomodoroTimer) StartTimer() {
-	pt.IsRunning = true
-	// TODO(map) Good enough for now but we should really count down the final break period too
-	for pt.IsRunning && pt.PomodoroSettings.IterationCount < pt.PomodoroSettings.Iterations {
-		if pt.CurrentTimer > 0 {
-			// Update timer
-			time.Sleep(time.Second * 1)
-			pt.CurrentTimer -= 1
-			pt.UpdateTimerText()
-		} else {
-			// Conditionally increment the counter only when finishing a focus period and refresh the text
-			if !pt.InBreakMode {
-				pt.PomodoroSettings.IterationCount += 1
-				pt.UpdateIterationText()
-			}
-
-			// Switch modes
-			pt.InBreakMode = !pt.InBreakMode
-
-			// Update the timer with the appropriate value after the previous timer finishes
-			if pt.InBreakMode {
-				pt.CurrentTimer = pt.PomodoroSettings.StartRelaxTime
-				pt.PomodoroTimerCanvas.ModeText.Text = "Relax"
-				pt.PomodoroTimerCanvas.ModeText.Refresh()
-			} else {
-				pt.CurrentTimer = pt.PomodoroSettings.StartFocusTime
-				pt.PomodoroTimerCanvas.ModeText.Text = "Focus"
-				pt.PomodoroTimerCanvas.ModeText.Refresh()
-			}
-			pt.UpdateTimerText()
+	pt.mu.Lock()
+	if pt.isRunning || pt.phase == CompletePhase || pt.settings.Iterations == 0 {
+		pt.mu.Unlock()
+		return
+	}
+	pt.isRunning = true
+	pt.deadline = pt.clock.Now().Add(pt.remaining)
+	pt.stop = make(chan struct{})
+	if pt.phaseStarted {
+		pt.emitLocked(TimerResumed)
+	} else {
+		pt.phaseStarted = true
+		pt.emitLocked(PhaseStarted)
+	}
+	go pt.countdown(pt.stop)
+	pt.mu.Unlock()
+
+	pt.publish()
+}
+
+// Pauses the timer if it is running and starts it otherwise
+func (pt *PomodoroTimer) ToggleTimer() {
+	pt.mu.Lock()
+	running := pt.isRunning
+	pt.mu.Unlock()
+
+	if running {
+		pt.PauseTimer()
+	} else {
+		pt.StartTimer()
+	}
+}
+
+func (pt *PomodoroTimer) countdown(stop chan struct{}) {
+	for {
+		pt.mu.Lock()
+		// Bail out if this loop was paused while it was waiting, a newer loop may already have taken over
+		select {
+		case <-stop:
+			pt.mu.Unlock()
+			return
+		default:
+		}
+
+		pt.remaining = pt.deadline.Sub(pt.clock.Now())
+		if pt.remaining <= 0 {
+			// Chain the next phase off the previous deadline instead of the current time so any lateness in waking
+			// up is absorbed rather than added on
+			previousDeadline := pt.deadline
+			pt.currentTimer = 0
+			pt.emitLocked(PhaseEnded)
+			pt.nextPhaseLocked()
+			pt.deadline = previousDeadline.Add(pt.remaining)
+			pt.mu.Unlock()
+			pt.publish()
+			continue
+		}
 
+		changed := false
+		if seconds := secondsLeft(pt.remaining); seconds != pt.currentTimer {
+			pt.currentTimer = seconds
+			changed = true
+		}
+
+		// Sleep until the displayed second ticks over
+		wait := pt.remaining % time.Second
+		if wait == 0 {
+			wait = time.Second
+		}
+		tick := pt.clock.NewTimer(wait)
+		pt.tick = tick
+		pt.mu.Unlock()
+
+		if changed {
+			pt.publish()
+		}
+		select {
+		case <-tick.C():
+		case <-stop:
+			return
 		}
 	}
 }
 
 func (pt *PomodoroTimer) PauseTimer() {
-	pt.IsRunning = false
+	pt.mu.Lock()
+	if pt.isRunning {
+		pt.remaining = pt.deadline.Sub(pt.clock.Now())
+		pt.currentTimer = secondsLeft(pt.remaining)
+		pt.haltLocked()
+		pt.emitLocked(TimerPaused)
+	}
+	pt.mu.Unlock()
+
+	pt.publish()
+}
+
+// Stops the countdown loop, waking it up if it is waiting on the next tick
+func (pt *PomodoroTimer) haltLocked() {
+	pt.isRunning = false
+	close(pt.stop)
+	if pt.tick != nil {
+		pt.tick.Stop()
+		pt.tick = nil
+	}
 }
 
 func (pt *PomodoroTimer) RestartTimer() {
-	pt.CurrentTimer = pt.PomodoroSettings.StartFocusTime
-	pt.PomodoroSettings.IterationCount = 0
-	pt.UpdateTimerText()
-	pt.UpdateIterationText()
-	pt.PomodoroTimerCanvas.ModeText.Text = "Focus"
-	pt.PomodoroTimerCanvas.ModeText.Refresh()
+	pt.mu.Lock()
+	pt.restartLocked()
+	pt.mu.Unlock()
+
+	pt.publish()
+}
+
+// Stops the countdown and goes back to the start of the first focus period. The timer is left paused so the new phase
+// is only started, and recorded as started, once the timer is started again.
+func (pt *PomodoroTimer) restartLocked() {
+	if pt.isRunning {
+		pt.remaining = pt.deadline.Sub(pt.clock.Now())
+		pt.currentTimer = secondsLeft(pt.remaining)
+		pt.haltLocked()
+	}
+	// Only a session that was under way is being abandoned, a fresh or finished one has nothing to record
+	if pt.phase != CompletePhase && (pt.phaseStarted || pt.iterationCount > 0) {
+		pt.emitLocked(TimerReset)
+	}
+	pt.phaseStarted = false
+	pt.iterationCount = 0
+	pt.phase = FocusPhase
+	pt.setRemainingLocked(pt.settings.StartFocusTime)
 }
 
-func (pt *PomodoroTimer) SetSettings(startFocusTime int, startRelaxTime int, iterations int) {
-	// NOTE(map) Multiply by 60 for the focus and relax time because the input units is in minutes but we track in seconds
-	// so the math is easier and so we can do one second increments on the timer itself.
-	pomodoroSettings := PomodoroSettings{
-		// TODO(map) Uncomment me after testing
-		// StartFocusTime: startFocusTime * 60,
-		StartFocusTime: startFocusTime,
-		// StartRelaxTime:   startRelaxTime * 60,
-		StartRelaxTime:   startRelaxTime,
-		Iterations:       iterations,
-		PauseDuringBreak: false,
+// Ends the current phase early and moves on to the next one
+func (pt *PomodoroTimer) SkipPhase() {
+	pt.mu.Lock()
+	if pt.phase == CompletePhase {
+		pt.mu.Unlock()
+		return
 	}
-	pt.PomodoroSettings = pomodoroSettings
+	pt.emitLocked(PhaseSkipped)
+	pt.nextPhaseLocked()
+	pt.mu.Unlock()
 
-	// Refresh the text to display to the user
-	pt.UpdateTimerText()
-	pt.UpdateIterationText()
+	pt.publish()
 }
 
-func (pt *PomodoroTimer) UpdateTimerText() {
-	// TODO(map) Render the time correctly based on the timer running or if reset is hit.
-	pt.PomodoroTimerCanvas.TimerText.Text = fmt.Sprintf(
-		"%d min %d sec",
-		int(pt.CurrentTimer/60),
-		int(pt.CurrentTimer%60),
-	)
-	pt.PomodoroTimerCanvas.TimerText.Refresh()
+func (pt *PomodoroTimer) nextPhaseLocked() {
+	// Conditionally increment the counter only when finishing a focus period
+	if pt.phase == FocusPhase {
+		pt.iterationCount += 1
+	}
+
+	// The session is over after the last break, or right after the last focus period if the final break is skipped
+	finished := pt.iterationCount >= pt.settings.Iterations
+	if finished && (pt.phase != FocusPhase || !pt.settings.CountFinalBreak) {
+		pt.completeSessionLocked()
+		return
+	}
+
+	// Switch modes and update the timer with the appropriate value after the previous timer finishes
+	if pt.phase == FocusPhase && pt.isLongBreakDueLocked() {
+		pt.phase = LongBreakPhase
+		pt.setRemainingLocked(pt.settings.StartLongBreakTime)
+	} else if pt.phase == FocusPhase {
+		pt.phase = RelaxPhase
+		pt.setRemainingLocked(pt.settings.StartRelaxTime)
+	} else {
+		pt.phase = FocusPhase
+		pt.setRemainingLocked(pt.settings.StartFocusTime)
+	}
+
+	// A paused timer only starts the new phase once it is resumed
+	pt.phaseStarted = pt.isRunning
+	if pt.phaseStarted {
+		pt.emitLocked(PhaseStarted)
+	}
 }
 
-func (pt *PomodoroTimer) UpdateIterationText() {
-	pt.PomodoroTimerCanvas.IterationText.Text = fmt.Sprintf(
-		"Completed %d of %d Iterations",
-		pt.PomodoroSettings.IterationCount,
-		pt.PomodoroSettings.Iterations,
-	)
-	pt.PomodoroTimerCanvas.IterationText.Refresh()
+func (pt *PomodoroTimer) completeSessionLocked() {
+	pt.phase = CompletePhase
+	pt.setRemainingLocked(0)
+	pt.phaseStarted = false
+	if pt.isRunning {
+		pt.haltLocked()
+	}
+	pt.emitLocked(SessionCompleted)
+	pt.pendingCompletion = true
 }
 
-func (pt *PomodoroTimer) CreateDefaultCanvas(library *library.Library, settings *pomoapp.Settings) {
-	circleContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(300, 300)),
-		canvas.NewCircle(color.RGBA{0, 0, 0, 255}),
-	)
-	modeText := canvas.NewText("Focus", color.RGBA{255, 255, 255, 255})
-	modeTextInnerContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
-		modeText,
-	)
-	modeTextContainer := container.New(layout.NewCenterLayout(), modeTextInnerContainer)
-	timerText := canvas.NewText("No Timer Created", color.RGBA{255, 255, 255, 255})
-	timerTextInnerContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
-		timerText,
-	)
-	timerTextContainer := container.New(layout.NewCenterLayout(), timerTextInnerContainer)
-	iterationText := canvas.NewText("", color.RGBA{255, 255, 255, 255})
-	iterationTextInnerContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
-		iterationText,
-	)
-	iterationTextContainer := container.New(layout.NewCenterLayout(), iterationTextInnerContainer)
-	playButton := widget.NewButton("Play", func() {
-		if !pt.IsRunning {
-			go pt.StartTimer()
-			if settings.LinkPlayers {
-				// TODO(map) Re-add code to have the players linked
-			}
-		} else {
-			pt.PauseTimer()
-			if settings.LinkPlayers {
-				// TODO(map) Re-add code to have the players linked
-			}
-		}
-	})
-	playButtonContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
-		playButton,
-	)
-	resetButton := widget.NewButton("Restart", func() {
-		pt.PauseTimer()
-		pt.RestartTimer()
-	})
-	resetButtonContainer := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(100, 50)),
-		resetButton,
-	)
-	textContainer := container.New(
-		layout.NewVBoxLayout(),
-		modeTextContainer,
-		timerTextContainer,
-		iterationTextContainer,
-	)
-	controlContainer := container.New(
-		layout.NewHBoxLayout(),
-		playButtonContainer,
-		resetButtonContainer,
-	)
-
-	textPlusControlContainer := container.NewVBox(textContainer, controlContainer)
-	topLevelContainer := container.New(
-		layout.NewCenterLayout(),
-		circleContainer,
-		textPlusControlContainer,
-	)
-
-	pt.PomodoroTimerCanvas = PomodoroTimerCanvas{
-		CircleContainer:             circleContainer,
-		ModeText:                    modeText,
-		ModeTextInnerContainer:      modeTextInnerContainer,
-		ModeTextContainer:           modeTextContainer,
-		TimerText:                   timerText,
-		TimerTextInnerContainer:     timerTextInnerContainer,
-		TimerTextContainer:          timerTextContainer,
-		IterationText:               iterationText,
-		IterationTextInnerContainer: iterationTextInnerContainer,
-		IterationTextContainer:      iterationTextContainer,
-		PlayButton:                  playButton,
-		PlayButtonContainer:         playButtonContainer,
-		ResetButton:                 resetButton,
-		ResetButtonContainer:        resetButtonContainer,
-		TextContainer:               textContainer,
-		ControlContainer:            controlContainer,
-		TextPlusControlContainer:    textPlusControlContainer,
-		TopLevelContainer:           topLevelContainer,
+// Whether the focus period that just finished has earned the long break rather than the regular relax
+func (pt *PomodoroTimer) isLongBreakDueLocked() bool {
+	interval := pt.settings.LongBreakInterval
+	return interval > 0 && pt.iterationCount%interval == 0
+}
+
+// Resets the countdown of the current phase to the given number of seconds
+func (pt *PomodoroTimer) setRemainingLocked(seconds int) {
+	pt.remaining = time.Duration(seconds) * time.Second
+	pt.currentTimer = seconds
+	if pt.isRunning {
+		pt.deadline = pt.clock.Now().Add(pt.remaining)
 	}
 }
 
-type PomodoroTimerCanvas struct {
-	// Circle container to hold all the data and controls
-	CircleContainer *fyne.Container
+// Rounds up so the display reads 25:00 for the whole first second of a 25 minute phase and 0:00 only once it's over
+func secondsLeft(remaining time.Duration) int {
+	return int((remaining + time.Second - 1) / time.Second)
+}
 
-	// Text to display mode
-	ModeText               *canvas.Text
-	ModeTextInnerContainer *fyne.Container
-	ModeTextContainer      *fyne.Container
+// Builds the settings for a timer. All times are in seconds, use ParseDuration to convert what the user typed in.
+func NewPomodoroSettings(
+	startFocusTime int,
+	startRelaxTime int,
+	startLongBreakTime int,
+	longBreakInterval int,
+	iterations int,
+	countFinalBreak bool,
+) PomodoroSettings {
+	return PomodoroSettings{
+		StartFocusTime:     startFocusTime,
+		StartRelaxTime:     startRelaxTime,
+		StartLongBreakTime: startLongBreakTime,
+		LongBreakInterval:  longBreakInterval,
+		Iterations:         iterations,
+		CountFinalBreak:    countFinalBreak,
+		PauseDuringBreak:   false,
+	}
+}
 
-	// Timer related components
-	TimerText               *canvas.Text
-	TimerTextInnerContainer *fyne.Container
-	TimerTextContainer      *fyne.Container
+func (pt *PomodoroTimer) SetSettings(pomodoroSettings PomodoroSettings) {
+	pt.mu.Lock()
+	pt.settings = pomodoroSettings
+	pt.presetName = ""
+	pt.mu.Unlock()
 
-	// Iterations related components
-	IterationText               *canvas.Text
-	IterationTextInnerContainer *fyne.Container
-	IterationTextContainer      *fyne.Container
+	// Let the subscribers know about the new totals
+	pt.publish()
+}
 
-	// Controls
-	PlayButton           *widget.Button
-	PlayButtonContainer  *fyne.Container
-	ResetButton          *widget.Button
-	ResetButtonContainer *fyne.Container
+// Loads the settings of a saved preset and restarts the timer with them
+func (pt *PomodoroTimer) ApplyPreset(preset Preset) {
+	pt.mu.Lock()
+	pt.settings = preset.Settings
+	pt.presetName = preset.Name
+	pt.restartLocked()
+	pt.mu.Unlock()
 
-	// Parent containers
-	TextContainer    *fyne.Container
-	ControlContainer *fyne.Container
+	pt.publish()
+}
 
-	// Wrapper
-	TextPlusControlContainer *fyne.Container
+// Follows a preset being renamed so the timer still knows where its settings came from
+func (pt *PomodoroTimer) RenamePreset(oldName string, newName string) {
+	pt.mu.Lock()
+	renamed := pt.presetName == oldName
+	if renamed {
+		pt.presetName = newName
+	}
+	pt.mu.Unlock()
 
-	// Top level container
-	TopLevelContainer *fyne.Container
+	if renamed {
+		pt.publish()
+	}
 }
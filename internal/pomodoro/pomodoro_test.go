@@ -0,0 +1,189 @@
+package pomodoro
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/clock"
+	"pomogoro/internal/eventtest"
+)
+
+// Gathers every event the timer sends out
+func recordEvents(pt *PomodoroTimer) *eventtest.Recorder[Event] {
+	recorder := &eventtest.Recorder[Event]{}
+	pt.SubscribeEvents(recorder.Record)
+	return recorder
+}
+
+func kinds(events []Event) []EventKind {
+	return eventtest.Collect(events, func(event Event) (EventKind, bool) {
+		return event.Kind, true
+	})
+}
+
+func newTestTimer(settings PomodoroSettings) (*PomodoroTimer, *clock.FakeClock) {
+	fc := clock.NewFakeClock(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	pt := NewPomodoroTimer(fc)
+	pt.SetSettings(settings)
+	pt.RestartTimer()
+	return pt, fc
+}
+
+// Waits for the countdown loop to go to sleep before moving the clock on a second at a time
+func advanceSeconds(pt *PomodoroTimer, fc *clock.FakeClock, seconds int) {
+	for i := 0; i < seconds; i++ {
+		fc.BlockUntil(1)
+		fc.Advance(time.Second)
+	}
+}
+
+func TestTimerCountsThroughFocusAndRelax(t *testing.T) {
+	pt, fc := newTestTimer(NewPomodoroSettings(3, 2, 0, 0, 1, true))
+	recorder := recordEvents(pt)
+	completed := make(chan State, 1)
+	pt.SubscribeSessionComplete(func(state State) {
+		completed <- state
+	})
+
+	pt.StartTimer()
+	advanceSeconds(pt, fc, 1)
+	fc.BlockUntil(1)
+	if state := pt.State(); state.Phase != FocusPhase || state.CurrentTimer != 2 {
+		t.Fatalf("after 1s got %v with %ds left, want Focus with 2s left", state.Phase, state.CurrentTimer)
+	}
+
+	advanceSeconds(pt, fc, 2)
+	fc.BlockUntil(1)
+	if state := pt.State(); state.Phase != RelaxPhase || state.CurrentTimer != 2 || state.IterationCount != 1 {
+		t.Fatalf("after 3s got %v with %ds left and %d iterations, want Relax with 2s left and 1 iteration",
+			state.Phase, state.CurrentTimer, state.IterationCount)
+	}
+
+	advanceSeconds(pt, fc, 2)
+	select {
+	case state := <-completed:
+		if state.Phase != CompletePhase || state.IsRunning {
+			t.Fatalf("completed in %v running %v, want a stopped Session Complete", state.Phase, state.IsRunning)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("session did not complete after the relax ran out")
+	}
+
+	want := []EventKind{PhaseStarted, PhaseEnded, PhaseStarted, PhaseEnded, SessionCompleted}
+	eventtest.AssertEqual(t, kinds(recorder.Events()), want)
+}
+
+func TestApplyPresetWhileRunningStopsTheCountdown(t *testing.T) {
+	pt, fc := newTestTimer(NewPomodoroSettings(60, 30, 0, 0, 2, false))
+	recorder := recordEvents(pt)
+
+	pt.StartTimer()
+	advanceSeconds(pt, fc, 10)
+	pt.ApplyPreset(Preset{Name: "Short", Settings: NewPomodoroSettings(5, 5, 0, 0, 1, false)})
+
+	state := pt.State()
+	if state.IsRunning || state.Phase != FocusPhase || state.CurrentTimer != 5 || state.PresetName != "Short" {
+		t.Fatalf("after applying the preset got %+v, want a stopped Focus with 5s left from Short", state)
+	}
+	// Nothing should still be counting down, so moving the clock on must not end a phase that never started
+	fc.Advance(time.Minute)
+	eventtest.AssertEqual(t, kinds(recorder.Events()), []EventKind{PhaseStarted, TimerReset})
+
+	completed := make(chan State, 1)
+	pt.SubscribeSessionComplete(func(state State) {
+		completed <- state
+	})
+	pt.StartTimer()
+	advanceSeconds(pt, fc, 5)
+	select {
+	case <-completed:
+	case <-time.After(time.Second):
+		t.Fatal("session did not complete after the new focus ran out")
+	}
+	eventtest.AssertEqual(t, kinds(recorder.Events()), []EventKind{PhaseStarted, TimerReset, PhaseStarted, PhaseEnded, SessionCompleted})
+}
+
+// Hammers the timer from several goroutines while the clock keeps moving. Run with -race to check the locking, the
+// events are checked to make sure only one countdown was ever running.
+func TestRapidPlayPauseRestart(t *testing.T) {
+	pt, fc := newTestTimer(NewPomodoroSettings(2, 1, 3, 2, 3, true))
+	recorder := recordEvents(pt)
+
+	done := make(chan struct{})
+	var advancing sync.WaitGroup
+	advancing.Add(1)
+	go func() {
+		defer advancing.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				fc.Advance(300 * time.Millisecond)
+			}
+		}
+	}()
+
+	actions := []func(){pt.ToggleTimer, pt.StartTimer, pt.PauseTimer, pt.RestartTimer, pt.SkipPhase, pt.ToggleTimer}
+	var drivers sync.WaitGroup
+	for i, action := range actions {
+		drivers.Add(1)
+		go func(i int, action func()) {
+			defer drivers.Done()
+			for n := 0; n < 300; n++ {
+				action()
+				if n%(i+2) == 0 {
+					pt.State()
+				}
+			}
+		}(i, action)
+	}
+	drivers.Wait()
+	pt.PauseTimer()
+	close(done)
+	advancing.Wait()
+
+	if pt.State().IsRunning {
+		t.Fatal("timer still running after being paused")
+	}
+
+	// Nothing may be left counting down once paused
+	settled := len(kinds(recorder.Events()))
+	fc.Advance(time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	if kinds := kinds(recorder.Events()); len(kinds) != settled {
+		t.Fatalf("events kept coming after the timer was paused: %v", kinds[settled:])
+	}
+
+	// Starts and resumes only ever happen to a stopped timer, or straight after the phase before it finished
+	running := false
+	var previous EventKind = -1
+	for i, kind := range kinds(recorder.Events()) {
+		switch kind {
+		case PhaseStarted:
+			if running && previous != PhaseEnded && previous != PhaseSkipped {
+				t.Fatalf("event %d: phase started while a countdown was already running", i)
+			}
+			running = true
+		case TimerResumed:
+			if running {
+				t.Fatalf("event %d: resumed while a countdown was already running", i)
+			}
+			running = true
+		case TimerPaused:
+			if !running {
+				t.Fatalf("event %d: paused while nothing was running", i)
+			}
+			running = false
+		case PhaseEnded:
+			if !running {
+				t.Fatalf("event %d: phase ended while nothing was running", i)
+			}
+		case TimerReset, SessionCompleted:
+			running = false
+		}
+		previous = kind
+	}
+}
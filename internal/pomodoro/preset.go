@@ -0,0 +1,201 @@
+package pomodoro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// A named timer configuration that can be picked again later instead of re-entering the times
+type Preset struct {
+	Name     string
+	Settings PomodoroSettings
+
+	// TODO(map) Hook these up to the player once playlists exist, for now they are only remembered
+	FocusPlaylist string
+	RelaxPlaylist string
+}
+
+// All the saved presets along with the file they are persisted to
+type PresetStore struct {
+	FilePath      string `json:"-"`
+	Presets       []Preset
+	DefaultPreset string // Name of the preset to load on startup, empty if there isn't one
+
+	subscriptions []presetSubscription
+	nextID        int
+}
+
+type presetSubscription struct {
+	id         int
+	subscriber func()
+}
+
+func NewPresetStore(filePath string) *PresetStore {
+	return &PresetStore{
+		FilePath: filePath,
+		Presets:  []Preset{},
+	}
+}
+
+// Registers a callback that is run every time the presets are changed so pickers can refresh their options. Returns the
+// function to call to stop it being run, for anything that goes away before the store does.
+func (store *PresetStore) Subscribe(subscriber func()) func() {
+	id := store.nextID
+	store.nextID += 1
+	store.subscriptions = append(store.subscriptions, presetSubscription{id: id, subscriber: subscriber})
+
+	return func() {
+		for i, subscription := range store.subscriptions {
+			if subscription.id == id {
+				store.subscriptions = append(store.subscriptions[:i], store.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (store *PresetStore) Load() error {
+	presetsFile, err := os.ReadFile(store.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing has been saved yet
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading presets: %w", err)
+	}
+
+	if err := json.Unmarshal(presetsFile, store); err != nil {
+		return fmt.Errorf("unmarshalling presets: %w", err)
+	}
+	store.notify()
+	return nil
+}
+
+func (store *PresetStore) Save() error {
+	file, err := json.MarshalIndent(store, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshalling presets: %w", err)
+	}
+	if err := os.WriteFile(store.FilePath, file, 0644); err != nil {
+		return fmt.Errorf("writing presets: %w", err)
+	}
+	store.notify()
+	return nil
+}
+
+func (store *PresetStore) notify() {
+	for _, subscription := range store.subscriptions {
+		subscription.subscriber()
+	}
+}
+
+func (store *PresetStore) Names() []string {
+	names := make([]string, len(store.Presets))
+	for i, preset := range store.Presets {
+		names[i] = preset.Name
+	}
+	return names
+}
+
+func (store *PresetStore) Find(name string) (Preset, bool) {
+	idx := store.indexOf(name)
+	if idx < 0 {
+		return Preset{}, false
+	}
+	return store.Presets[idx], true
+}
+
+func (store *PresetStore) Default() (Preset, bool) {
+	if store.DefaultPreset == "" {
+		return Preset{}, false
+	}
+	return store.Find(store.DefaultPreset)
+}
+
+// Adds a new preset and saves, failing if there is already one with the same name
+func (store *PresetStore) Add(preset Preset) error {
+	if store.indexOf(preset.Name) >= 0 {
+		return fmt.Errorf("preset %q already exists", preset.Name)
+	}
+	return store.Put(preset)
+}
+
+// Adds the preset, replacing the existing one with the same name if there is one, and saves
+func (store *PresetStore) Put(preset Preset) error {
+	if preset.Name == "" {
+		return errors.New("preset name cannot be empty")
+	}
+
+	if idx := store.indexOf(preset.Name); idx >= 0 {
+		store.Presets[idx] = preset
+	} else {
+		store.Presets = append(store.Presets, preset)
+	}
+	return store.Save()
+}
+
+func (store *PresetStore) Rename(oldName string, newName string) error {
+	idx := store.indexOf(oldName)
+	if idx < 0 {
+		return fmt.Errorf("preset %q does not exist", oldName)
+	}
+	if newName == "" {
+		return errors.New("preset name cannot be empty")
+	}
+	if newName != oldName && store.indexOf(newName) >= 0 {
+		return fmt.Errorf("preset %q already exists", newName)
+	}
+
+	store.Presets[idx].Name = newName
+	if store.DefaultPreset == oldName {
+		store.DefaultPreset = newName
+	}
+	return store.Save()
+}
+
+// Copies the preset under the first free "<name> (copy)" style name and returns the new preset
+func (store *PresetStore) Duplicate(name string) (Preset, error) {
+	preset, ok := store.Find(name)
+	if !ok {
+		return Preset{}, fmt.Errorf("preset %q does not exist", name)
+	}
+
+	preset.Name = name + " (copy)"
+	for i := 2; store.indexOf(preset.Name) >= 0; i++ {
+		preset.Name = fmt.Sprintf("%s (copy %d)", name, i)
+	}
+	store.Presets = append(store.Presets, preset)
+	return preset, store.Save()
+}
+
+func (store *PresetStore) Delete(name string) error {
+	idx := store.indexOf(name)
+	if idx < 0 {
+		return fmt.Errorf("preset %q does not exist", name)
+	}
+
+	store.Presets = append(store.Presets[:idx], store.Presets[idx+1:]...)
+	if store.DefaultPreset == name {
+		store.DefaultPreset = ""
+	}
+	return store.Save()
+}
+
+func (store *PresetStore) SetDefault(name string) error {
+	if store.indexOf(name) < 0 {
+		return fmt.Errorf("preset %q does not exist", name)
+	}
+
+	store.DefaultPreset = name
+	return store.Save()
+}
+
+func (store *PresetStore) indexOf(name string) int {
+	for i, preset := range store.Presets {
+		if preset.Name == name {
+			return i
+		}
+	}
+	return -1
+}
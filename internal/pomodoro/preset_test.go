@@ -0,0 +1,40 @@
+package pomodoro
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPresetStoreAddRefusesExistingName(t *testing.T) {
+	store := NewPresetStore(filepath.Join(t.TempDir(), "presets.json"))
+	original := Preset{Name: "Work", Settings: NewPomodoroSettings(1500, 300, 0, 0, 4, false)}
+	if err := store.Add(original); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Add(Preset{Name: "Work", Settings: NewPomodoroSettings(60, 60, 0, 0, 1, false)}); err == nil {
+		t.Fatal("adding a preset with a name that is taken succeeded")
+	}
+	if preset, _ := store.Find("Work"); preset.Settings != original.Settings {
+		t.Fatalf("existing preset was changed to %+v", preset.Settings)
+	}
+}
+
+func TestPresetStoreUnsubscribe(t *testing.T) {
+	store := NewPresetStore(filepath.Join(t.TempDir(), "presets.json"))
+	kept, dropped := 0, 0
+	store.Subscribe(func() { kept += 1 })
+	unsubscribe := store.Subscribe(func() { dropped += 1 })
+
+	if err := store.Put(Preset{Name: "Work"}); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribe()
+	if err := store.Put(Preset{Name: "Rest"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if kept != 2 || dropped != 1 {
+		t.Fatalf("subscribers were notified %d and %d times, want 2 and 1", kept, dropped)
+	}
+}
@@ -0,0 +1,164 @@
+package song
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+)
+
+// A song in a batch that couldn't be saved and why
+type SaveFailure struct {
+	Song *Song
+	Err  error
+}
+
+// Returned when a batch of songs couldn't be saved. Songs already saved are put back how they were so either every
+// song in the batch changes or none of them do, unless putting them back failed too.
+type BatchError struct {
+	Failures         []SaveFailure // Songs that stopped the batch from being saved
+	RollbackFailures []SaveFailure // Songs that were saved but couldn't be put back
+}
+
+func (err *BatchError) Error() string {
+	if len(err.RollbackFailures) > 0 {
+		return fmt.Sprintf(
+			"could not save the tags for %d songs and %d of the ones saved couldn't be put back",
+			len(err.Failures),
+			len(err.RollbackFailures),
+		)
+	}
+	return fmt.Sprintf("could not save the tags for %d songs so none were changed", len(err.Failures))
+}
+
+// Saves the edit to every song's details, all or nothing. The fields the edit changes are checked before any file is
+// touched, and if a file still fails to save the ones before it are put back exactly as they were. Progress is reported
+// after each song is saved.
+func SaveDetailsBatch(songs []*Song, edit func(Details) Details, progress func(done int, total int)) error {
+	// The details are read from the files so nothing else in them is lost if the library is out of date
+	originals := make([]Details, len(songs))
+	originalTags := make([][]byte, len(songs))
+	batchErr := &BatchError{}
+	for i, song := range songs {
+		if err := song.CanSaveDetails(); err != nil {
+			batchErr.Failures = append(batchErr.Failures, SaveFailure{Song: song, Err: err})
+			continue
+		}
+		original, err := song.FileDetails()
+		if err == nil {
+			originalTags[i], err = readTagBytes(song.FilePath)
+		}
+		if err == nil {
+			err = edit(original).validateChanged(original)
+		}
+		if err != nil {
+			batchErr.Failures = append(batchErr.Failures, SaveFailure{Song: song, Err: err})
+			continue
+		}
+		originals[i] = original
+	}
+	if len(batchErr.Failures) > 0 {
+		return batchErr
+	}
+
+	for i, song := range songs {
+		if err := song.saveDetails(edit(originals[i])); err != nil {
+			batchErr.Failures = append(batchErr.Failures, SaveFailure{Song: song, Err: err})
+
+			// Undo the ones that made it, latest first
+			for j := i - 1; j >= 0; j-- {
+				if err := restoreTagBytes(songs[j].FilePath, originalTags[j]); err != nil {
+					err = fmt.Errorf("could not put back the tags for %s: %w", songs[j].Name, err)
+					batchErr.RollbackFailures = append(batchErr.RollbackFailures, SaveFailure{Song: songs[j], Err: err})
+					continue
+				}
+				songs[j].setDetails(originals[j])
+			}
+			return batchErr
+		}
+		if progress != nil {
+			progress(i+1, len(songs))
+		}
+	}
+	return nil
+}
+
+// Size of the ID3v2 tag at the start of the file, 0 if it doesn't have one
+func tagSize(file io.Reader) (int64, error) {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(file, header); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return audio.ID3Size(header), nil
+}
+
+// The ID3v2 tag at the start of the file byte for byte, empty if it doesn't have one
+func readTagBytes(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	size, err := tagSize(file)
+	if err != nil {
+		return nil, err
+	}
+	tagBytes := make([]byte, size)
+	if _, err := file.ReadAt(tagBytes, 0); err != nil {
+		return nil, err
+	}
+	return tagBytes, nil
+}
+
+// Puts the tag read by readTagBytes back in place of whatever tag the file starts with now. Nothing about it is checked
+// since it is exactly what the file had before. The file is written alongside and moved over the original the same
+// way tags are saved, so a failure part way leaves the file as it was.
+func restoreTagBytes(path string, tagBytes []byte) (err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size, err := tagSize(file)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+
+	restored, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-restore-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			restored.Close()
+			os.Remove(restored.Name())
+		}
+	}()
+	if _, err := restored.Write(tagBytes); err != nil {
+		return err
+	}
+	if _, err := io.Copy(restored, file); err != nil {
+		return err
+	}
+	if err := restored.Chmod(info.Mode()); err != nil {
+		return err
+	}
+	if err := restored.Close(); err != nil {
+		return err
+	}
+	file.Close()
+	return os.Rename(restored.Name(), path)
+}
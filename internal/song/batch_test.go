@@ -0,0 +1,126 @@
+package song
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// ID3
+	"github.com/bogem/id3v2"
+)
+
+// Writes an MP3 file whose tag has a track number from a vinyl side, which wouldn't pass being checked, alongside a
+// comment of the kind the details leave alone
+func writeTaggedMP3(t *testing.T, dir string, name string) *Song {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, bytes.Repeat([]byte{0xff, 0xfb, 0x90, 0x00}, 256), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	tag.SetTitle(name)
+	tag.SetArtist("Original Artist")
+	tag.AddTextFrame(tag.CommonID("Track number/Position in set"), tag.DefaultEncoding(), "A1")
+	tag.AddCommentFrame(id3v2.CommentFrame{
+		Encoding:    id3v2.EncodingISO,
+		Language:    "eng",
+		Description: "iTunNORM",
+		Text:        "00000A1B 00000C2D",
+	})
+	if err := tag.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return NewSong(dir, name)
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return contents
+}
+
+func setArtist(artist string) func(Details) Details {
+	return func(details Details) Details {
+		details.Artist = artist
+		return details
+	}
+}
+
+func TestSaveDetailsBatchOnlyChecksChangedFields(t *testing.T) {
+	dir := t.TempDir()
+	songs := []*Song{writeTaggedMP3(t, dir, "a.mp3"), writeTaggedMP3(t, dir, "b.mp3")}
+
+	if err := SaveDetailsBatch(songs, setArtist("New Artist"), nil); err != nil {
+		t.Fatalf("saving a field that is fine was stopped by one that wasn't changed: %v", err)
+	}
+	for _, batchSong := range songs {
+		details, err := batchSong.FileDetails()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Artist != "New Artist" || details.Track != "A1" {
+			t.Fatalf("%s has artist %q and track %q, want New Artist and A1", batchSong.Name, details.Artist, details.Track)
+		}
+	}
+}
+
+func TestSaveDetailsBatchChecksChangedFields(t *testing.T) {
+	dir := t.TempDir()
+	songs := []*Song{writeTaggedMP3(t, dir, "a.mp3"), writeTaggedMP3(t, dir, "b.mp3")}
+	before := readFile(t, songs[0].FilePath)
+
+	err := SaveDetailsBatch(songs, func(details Details) Details {
+		details.Year = "97"
+		return details
+	}, nil)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failures) != len(songs) {
+		t.Fatalf("saving a bad year returned %v, want every song to fail", err)
+	}
+	if !bytes.Equal(readFile(t, songs[0].FilePath), before) {
+		t.Fatal("a file was changed even though the batch was refused")
+	}
+}
+
+func TestSaveDetailsBatchPutsFilesBackExactly(t *testing.T) {
+	dir := t.TempDir()
+	songs := []*Song{
+		writeTaggedMP3(t, dir, "a.mp3"),
+		writeTaggedMP3(t, dir, "b.mp3"),
+		writeTaggedMP3(t, dir, "c.mp3"),
+	}
+	before := [][]byte{readFile(t, songs[0].FilePath), readFile(t, songs[1].FilePath)}
+
+	// The last song disappears once the batch is under way so it can't be saved
+	err := SaveDetailsBatch(songs, setArtist("New Artist"), func(done int, total int) {
+		if done == 1 {
+			if err := os.Remove(songs[2].FilePath); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failures) != 1 || batchErr.Failures[0].Song != songs[2] {
+		t.Fatalf("saving returned %v, want just c.mp3 to fail", err)
+	}
+	if len(batchErr.RollbackFailures) > 0 {
+		t.Fatalf("could not put songs back: %v", batchErr.RollbackFailures)
+	}
+	for i, want := range before {
+		if !bytes.Equal(readFile(t, songs[i].FilePath), want) {
+			t.Fatalf("%s isn't byte for byte how it was before the batch", songs[i].Name)
+		}
+		if artist := songs[i].Details().Artist; artist != "Original Artist" {
+			t.Fatalf("%s still shows artist %q after being put back", songs[i].Name, artist)
+		}
+	}
+}
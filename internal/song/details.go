@@ -0,0 +1,248 @@
+package song
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+
+	// ID3
+	"github.com/bogem/id3v2"
+)
+
+// ID3 tags can only be written safely to MP3 files, putting one at the front of any other format breaks it
+var ErrTagsNotSupported = errors.New("tags can only be saved to MP3 files")
+
+// The parts of a song's tag that can be edited
+type Details struct {
+	Title   string
+	Artist  string
+	Album   string
+	Genre   string
+	Track   string // Track number on its own or out of the total such as 3/12
+	Year    string
+	Comment string
+}
+
+// Reads the details out of the tag, leaving out whatever it doesn't have
+func ReadDetails(tag *id3v2.Tag) Details {
+	if tag == nil {
+		return Details{}
+	}
+	details := Details{
+		Title:  tag.Title(),
+		Artist: tag.Artist(),
+		Album:  tag.Album(),
+		Genre:  tag.Genre(),
+		Track:  tag.GetTextFrame(tag.CommonID("Track number/Position in set")).Text,
+		Year:   tag.Year(),
+	}
+	if comment, index := shownComment(tag.GetFrames(tag.CommonID("Comments"))); index >= 0 {
+		details.Comment = comment.Text
+	}
+	return details
+}
+
+// The comment that is shown and edited out of the comment frames along with where it is, -1 if there isn't one. It is
+// the last one without a description, the ones with a description such as iTunNORM are left to whatever wrote them.
+func shownComment(frames []id3v2.Framer) (id3v2.CommentFrame, int) {
+	for i := len(frames) - 1; i >= 0; i-- {
+		if comment, ok := frames[i].(id3v2.CommentFrame); ok && comment.Description == "" {
+			return comment, i
+		}
+	}
+	return id3v2.CommentFrame{}, -1
+}
+
+// Sets the details on the tag, taking out the frames of any that are empty
+func (details Details) WriteTo(tag *id3v2.Tag) {
+	setText := func(description string, text string) {
+		id := tag.CommonID(description)
+		if text == "" {
+			tag.DeleteFrames(id)
+		} else {
+			tag.AddTextFrame(id, tag.DefaultEncoding(), text)
+		}
+	}
+	setText("Title", details.Title)
+	setText("Artist", details.Artist)
+	setText("Album/Movie/Show title", details.Album)
+	setText("Content type", details.Genre)
+	setText("Track number/Position in set", details.Track)
+	setText("Year", details.Year)
+
+	// A tag can hold several comments, such as iTunNORM or ones in other languages. Only the one ReadDetails shows is
+	// replaced and the rest are put back as they were.
+	commentID := tag.CommonID("Comments")
+	comments := append([]id3v2.Framer{}, tag.GetFrames(commentID)...)
+	comment := id3v2.CommentFrame{
+		Encoding: tag.DefaultEncoding(),
+		Language: "eng",
+		Text:     details.Comment,
+	}
+	if shown, index := shownComment(comments); index >= 0 {
+		comment.Language = shown.Language
+		comments = append(comments[:index], comments[index+1:]...)
+	}
+	tag.DeleteFrames(commentID)
+	for _, kept := range comments {
+		tag.AddFrame(commentID, kept)
+	}
+	if details.Comment != "" {
+		tag.AddCommentFrame(comment)
+	}
+}
+
+func (details Details) Validate() error {
+	if err := ValidateTrack(details.Track); err != nil {
+		return err
+	}
+	return ValidateYear(details.Year)
+}
+
+// Checks only the fields that differ from the original, so whatever a file already had that wouldn't pass doesn't
+// stop other fields being changed
+func (details Details) validateChanged(original Details) error {
+	if details.Track != original.Track {
+		if err := ValidateTrack(details.Track); err != nil {
+			return err
+		}
+	}
+	if details.Year != original.Year {
+		return ValidateYear(details.Year)
+	}
+	return nil
+}
+
+// Checks the track is empty, a number or a number out of a total such as 3/12
+func ValidateTrack(track string) error {
+	if track == "" {
+		return nil
+	}
+	number, total, hasTotal := strings.Cut(track, "/")
+	trackNumber, err := strconv.Atoi(number)
+	if err != nil || trackNumber < 1 {
+		return fmt.Errorf("track must be a number from 1 up, such as 3 or 3/12")
+	}
+	if hasTotal {
+		trackTotal, err := strconv.Atoi(total)
+		if err != nil || trackTotal < trackNumber {
+			return fmt.Errorf("track total must be a number no less than the track")
+		}
+	}
+	return nil
+}
+
+// How far an ID3v2.4 recording time can go, from just the year down to the second
+var yearLayouts = []string{
+	"2006",
+	"2006-01",
+	"2006-01-02",
+	"2006-01-02T15",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+}
+
+// Checks the year is empty, four digits or a date starting with them such as 2001-05-12
+func ValidateYear(year string) error {
+	if year == "" {
+		return nil
+	}
+	for _, layout := range yearLayouts {
+		if len(year) != len(layout) {
+			continue
+		}
+		if _, err := time.Parse(layout, year); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("year must be four digits or a date, such as 1997 or 1997-05-12")
+}
+
+// The details currently in the song's tag
+func (song *Song) Details() Details {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	return ReadDetails(song.tag)
+}
+
+// Fills in what is known about the song without reading its file, such as from the library cache
+func (song *Song) Describe(details Details, length time.Duration) {
+	tag := id3v2.NewEmptyTag()
+	details.WriteTo(tag)
+
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	song.tag = tag
+	song.length = length
+}
+
+// Name to show for the song, its title if it has one or else its file name
+func (song *Song) DisplayName() string {
+	if title := song.Details().Title; title != "" {
+		return title
+	}
+	return song.Name
+}
+
+// Checks the song's file is one that tags can be saved to
+func (song *Song) CanSaveDetails() error {
+	format, err := audio.DetectFormat(song.FilePath)
+	if err != nil {
+		return fmt.Errorf("could not save the tags for %s: %w", song.Name, err)
+	}
+	if format.Name != "MP3" {
+		return fmt.Errorf("could not save the tags for %s: %w", song.Name, ErrTagsNotSupported)
+	}
+	return nil
+}
+
+// The details as they are in the file right now, rather than as they were when the library was loaded
+func (song *Song) FileDetails() (Details, error) {
+	tag, err := id3v2.Open(song.FilePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return Details{}, fmt.Errorf("could not read the tags for %s: %w", song.Name, err)
+	}
+	defer tag.Close()
+	return ReadDetails(tag), nil
+}
+
+// Writes the details into the file's tag and then onto the song once they have been saved
+func (song *Song) SaveDetails(details Details) error {
+	if err := details.Validate(); err != nil {
+		return err
+	}
+	if err := song.CanSaveDetails(); err != nil {
+		return err
+	}
+	return song.saveDetails(details)
+}
+
+// Writes the details the same as SaveDetails without checking them first, for when that has already been done
+func (song *Song) saveDetails(details Details) error {
+	tag, err := id3v2.Open(song.FilePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("could not read the tags for %s: %w", song.Name, err)
+	}
+	defer tag.Close()
+	details.WriteTo(tag)
+	if err := tag.Save(); err != nil {
+		return fmt.Errorf("could not save the tags for %s: %w", song.Name, err)
+	}
+	song.setDetails(details)
+	return nil
+}
+
+// Swaps the song's copy of its tag for the details, so it doesn't hold the file open
+func (song *Song) setDetails(details Details) {
+	tag := id3v2.NewEmptyTag()
+	details.WriteTo(tag)
+
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	song.tag = tag
+}
@@ -0,0 +1,132 @@
+package song
+
+import (
+	"testing"
+
+	// ID3
+	"github.com/bogem/id3v2"
+)
+
+func TestValidateYear(t *testing.T) {
+	tests := []struct {
+		year  string
+		valid bool
+	}{
+		{"", true},
+		{"1997", true},
+		{"2001-05", true},
+		{"2001-05-12", true},
+		{"2001-05-12T08", true},
+		{"2001-05-12T08:30", true},
+		{"2001-05-12T08:30:15", true},
+		{"97", false},
+		{"19970", false},
+		{"199a", false},
+		{"2001-13", false},
+		{"2001-05-32", false},
+		{"2001/05/12", false},
+		{"2001-05-12 08:30", false},
+	}
+	for _, test := range tests {
+		err := ValidateYear(test.year)
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("ValidateYear(%q) = %v, want valid %v", test.year, err, test.valid)
+		}
+	}
+}
+
+func TestDetailsReadFromAVersionFourTag(t *testing.T) {
+	tag := id3v2.NewEmptyTag()
+	tag.SetVersion(4)
+	tag.AddTextFrame(tag.CommonID("Year"), tag.DefaultEncoding(), "2001-05-12")
+
+	details := ReadDetails(tag)
+	if details.Year != "2001-05-12" {
+		t.Fatalf("read year %q, want 2001-05-12", details.Year)
+	}
+	if err := details.Validate(); err != nil {
+		t.Fatalf("details read from the tag don't validate: %v", err)
+	}
+}
+
+// Tag holding a French comment, then the English one the details show and an iTunes volume comment after it
+func newCommentedTag() *id3v2.Tag {
+	tag := id3v2.NewEmptyTag()
+	for _, comment := range []id3v2.CommentFrame{
+		{Encoding: id3v2.EncodingUTF8, Language: "fra", Text: "Bonjour"},
+		{Encoding: id3v2.EncodingUTF8, Language: "eng", Text: "Hello"},
+		{Encoding: id3v2.EncodingISO, Language: "eng", Description: "iTunNORM", Text: "00000A1B 00000C2D"},
+	} {
+		tag.AddCommentFrame(comment)
+	}
+	return tag
+}
+
+func comments(tag *id3v2.Tag) map[string]string {
+	found := map[string]string{}
+	for _, frame := range tag.GetFrames(tag.CommonID("Comments")) {
+		comment := frame.(id3v2.CommentFrame)
+		found[comment.Language+"/"+comment.Description] = comment.Text
+	}
+	return found
+}
+
+func TestWriteToOnlyReplacesTheCommentThatWasRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		comment  string
+		want     map[string]string
+		wantRead string // The comment shown afterwards, the French one once the English one is gone
+	}{
+		{
+			name:     "changed",
+			comment:  "Hi there",
+			want:     map[string]string{"eng/iTunNORM": "00000A1B 00000C2D", "fra/": "Bonjour", "eng/": "Hi there"},
+			wantRead: "Hi there",
+		},
+		{
+			name:     "unchanged",
+			comment:  "Hello",
+			want:     map[string]string{"eng/iTunNORM": "00000A1B 00000C2D", "fra/": "Bonjour", "eng/": "Hello"},
+			wantRead: "Hello",
+		},
+		{
+			name:     "cleared",
+			comment:  "",
+			want:     map[string]string{"eng/iTunNORM": "00000A1B 00000C2D", "fra/": "Bonjour"},
+			wantRead: "Bonjour",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tag := newCommentedTag()
+			details := ReadDetails(tag)
+			if details.Comment != "Hello" {
+				t.Fatalf("read comment %q, want Hello", details.Comment)
+			}
+
+			details.Comment = test.comment
+			details.WriteTo(tag)
+			got := comments(tag)
+			if len(got) != len(test.want) {
+				t.Fatalf("tag has comments %v, want %v", got, test.want)
+			}
+			for key, text := range test.want {
+				if got[key] != text {
+					t.Fatalf("tag has comments %v, want %v", got, test.want)
+				}
+			}
+			if read := ReadDetails(tag).Comment; read != test.wantRead {
+				t.Fatalf("read back comment %q, want %q", read, test.wantRead)
+			}
+		})
+	}
+}
+
+func TestWriteToAddsACommentToATagWithout(t *testing.T) {
+	tag := id3v2.NewEmptyTag()
+	Details{Comment: "First"}.WriteTo(tag)
+	if got := comments(tag); len(got) != 1 || got["eng/"] != "First" {
+		t.Fatalf("tag has comments %v, want just eng/ First", got)
+	}
+}
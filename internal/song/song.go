@@ -1,37 +1,44 @@
 package song
 
 import (
-	"fmt"
+	"errors"
 	"log"
-	"os"
+	"sync"
 	"time"
 
 	// Internal imports
+	"pomogoro/internal/audio"
 	"pomogoro/internal/messages"
 
-	// MP3 imports
-	"github.com/hajimehoshi/go-mp3"
-	"github.com/hajimehoshi/oto/v2"
-
 	// ID3
 	"github.com/bogem/id3v2"
 )
 
+const pollInterval = 100 * time.Millisecond // How often a playing song checks whether it has finished
+
+// A song in the library. Playing it happens on its own goroutine while the controls, the progress display, the
+// transition watcher and library refreshes all poke at it from theirs, so its tag and everything about how it is
+// playing is guarded by mu.
 type Song struct {
 	Name     string
 	FilePath string
-	Skipped  bool
-	Tag      *id3v2.Tag
+
+	mu      sync.Mutex
+	tag     *id3v2.Tag
+	length  time.Duration // How long the song is as found when the library was scanned, 0 if it isn't known
+	skipped bool
+	volume  float64 // Volume to play at between 0 and 1
+
+	// Set once the player has already moved on to the next song, so this one finishing or being stopped shouldn't be
+	// reported back to it
+	handedOff bool
+	paused    bool
 
 	// Used just for accessing the player for functionality. The file has to be opened and be streamed so having an
 	// instance of the player actually being initialized doesn't work too well unless I wanted to keep the bytes of the
 	// file in memory.
-	Player oto.Player
-}
-
-func (song *Song) ApplyTag(libraryPath string, songName string) {
-	tag, _ := id3v2.Open(libraryPath+"/"+songName, id3v2.Options{Parse: true})
-	song.Tag = tag
+	player audio.Player
+	stream *audio.FileStream
 }
 
 func NewSong(libraryPath string, songName string) *Song {
@@ -41,75 +48,282 @@ func NewSong(libraryPath string, songName string) *Song {
 	}
 }
 
-func (song *Song) Play(songControlsChan chan messages.ChannelMessage) {
-	// Open the file to stream the contents
-	f, err := os.Open(song.FilePath)
-	if err != nil {
-		log.Println("Err opening file")
-		panic(err)
+// Opens the song and gets it ready to play without starting it, so it can start the moment it's needed
+func (song *Song) Load(output audio.AudioOutput) error {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	return song.loadLocked(output)
+}
+
+func (song *Song) loadLocked(output audio.AudioOutput) error {
+	if song.player != nil {
+		return nil
+	}
+	if err := song.openLocked(); err != nil {
+		return err
 	}
-	defer f.Close()
+	song.player = output.NewPlayer(song.stream)
+	song.player.SetVolume(song.volume)
+	return nil
+}
 
-	d, err := mp3.NewDecoder(f)
+// Opens the file and picks the decoder for its format
+func (song *Song) openLocked() error {
+	stream, err := audio.OpenFile(song.FilePath)
 	if err != nil {
-		log.Println("Err setting up decoder")
-		panic(err)
+		return err
 	}
+	song.stream = stream
+	return nil
+}
 
-	c, ready, err := oto.NewContext(d.SampleRate(), 2, 2)
-	if err != nil {
-		panic(err)
+// Lines the song up to be played straight on from the end of the previous one through the same player, so not a
+// moment passes between them. Returns a channel that is closed once the previous song has run out into this one, at
+// which point PlayOnFrom has to be called to follow it.
+func (song *Song) ChainAfter(previous *Song) (<-chan struct{}, error) {
+	song.mu.Lock()
+	if song.player != nil {
+		song.mu.Unlock()
+		return nil, errors.New("song is already playing")
+	}
+	if err := song.openLocked(); err != nil {
+		song.mu.Unlock()
+		return nil, err
 	}
-	<-ready
+	stream := song.stream
+	song.mu.Unlock()
 
-	song.Player = c.NewPlayer(d)
-	song.Player.SetVolume(0.1)
-	song.Player.Play()
+	previous.mu.Lock()
+	defer previous.mu.Unlock()
+	if previous.player == nil {
+		stream.Close()
+		return nil, errors.New("previous song is not playing")
+	}
+	return previous.player.Chain(stream), nil
+}
+
+// Takes back the song chained on to the end of this one. Returns false if this song has already run out into it.
+func (song *Song) Unchain() bool {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	if song.player == nil {
+		return true
+	}
+	return song.player.Unchain()
+}
+
+// Lets go of a song that was loaded but never ended up being played
+func (song *Song) Unload() {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	if song.player != nil {
+		song.player.Close()
+		song.player = nil
+	}
+	if song.stream != nil {
+		song.stream.Close()
+		song.stream = nil
+	}
+}
+
+// Streams the song through the shared output until it finishes, is skipped or is stopped, publishing what happens to
+// it on the bus
+func (song *Song) Play(output audio.AudioOutput, events *messages.Bus) {
+	song.mu.Lock()
+	if err := song.loadLocked(output); err != nil {
+		song.mu.Unlock()
+		log.Printf("Err setting up decoder for %s: %v", song.Name, err)
+		song.publish(events, messages.TrackError, err)
+		return
+	}
+	stream := song.stream
+	defer stream.Close()
 
-	// Wait for the song to finish playing
+	song.handedOff = false
+	song.paused = false
+	song.player.SetVolume(song.volume)
+	song.player.Play()
+	song.mu.Unlock()
+	song.publish(events, messages.TrackStarted, nil)
+	song.follow(events)
+}
+
+// Takes over the player of the previous song once it has run out into this one after ChainAfter, then follows it the
+// same as Play would. The previous song ends quietly since the player has already moved on from it.
+func (song *Song) PlayOnFrom(previous *Song, events *messages.Bus) {
+	previous.mu.Lock()
+	player := previous.player
+	paused := previous.paused
+	previous.player = nil
+	previous.handedOff = true
+	previous.mu.Unlock()
+
+	song.mu.Lock()
+	stream := song.stream
+	defer stream.Close()
+	if player == nil {
+		// The previous song was stopped just as it ran out so there is nothing to carry on
+		song.stream = nil
+		song.mu.Unlock()
+		return
+	}
+	song.player = player
+	song.handedOff = false
+	song.paused = paused
+	song.player.SetVolume(song.volume)
+	song.mu.Unlock()
+	song.publish(events, messages.TrackStarted, nil)
+	song.follow(events)
+}
+
+// Waits for the song to finish playing, publishing how it ended
+func (song *Song) follow(events *messages.Bus) {
 	for {
-		time.Sleep(time.Second)
-		if song.Skipped {
-			// Reset skipped flag and published the skipped message
-			song.Skipped = false
-			songControlsChan <- messages.ChannelMessage{SongSkipped: true}
-			break
-		} else if song.Player == nil {
-			// Song was stopped so we published stopped message
-			fmt.Println("Publishing stopped message")
-			songControlsChan <- messages.ChannelMessage{SongStopped: true}
-			fmt.Println("Published stopped message")
-			break
-		} else if !song.Player.IsPlaying() {
-			// The song was ended by playing to completion and we should publish the message and break
-			if song.Player.UnplayedBufferSize() == 0 {
-				fmt.Println("Publishing completed message")
-				songControlsChan <- messages.ChannelMessage{SongFinished: true}
-				fmt.Println("Published completed message")
-				break
+		time.Sleep(pollInterval)
+		song.mu.Lock()
+		if song.handedOff && song.isDoneLocked() {
+			// The player has moved on without waiting for this song so there is nobody to tell
+			song.handedOff = false
+			song.stopLocked(false)
+			song.mu.Unlock()
+			return
+		} else if song.skipped {
+			// Reset skipped flag and publish the skipped event
+			song.skipped = false
+			song.mu.Unlock()
+			song.publish(events, messages.TrackSkipped, nil)
+			return
+		} else if song.player == nil {
+			// Song was stopped
+			song.mu.Unlock()
+			song.publish(events, messages.TrackStopped, nil)
+			return
+		} else if song.isDoneLocked() {
+			// The song ended by playing to completion unless the output gave up on it part way through. It is let go of
+			// first so it can be played again from the start.
+			err := song.player.Err()
+			song.stopLocked(false)
+			song.mu.Unlock()
+			if err != nil {
+				song.publish(events, messages.TrackError, err)
+			} else {
+				song.publish(events, messages.TrackFinished, nil)
 			}
+			return
 		}
+		song.mu.Unlock()
+	}
+}
+
+// Whether the song has been stopped or has played all the way through
+func (song *Song) isDoneLocked() bool {
+	return song.player == nil || (!song.paused && !song.player.IsPlaying() && song.player.UnplayedBufferSize() == 0)
+}
+
+// Whether the song has been loaded or is playing, as opposed to never having been started or having been stopped
+func (song *Song) IsLoaded() bool {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	return song.player != nil
+}
+
+func (song *Song) IsPlaying() bool {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	return song.player != nil && song.player.IsPlaying()
+}
+
+// Marks the song as one the player has already moved on from so it ends quietly
+func (song *Song) HandOff() {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	song.handedOff = true
+}
+
+func (song *Song) Resume(events *messages.Bus) {
+	song.mu.Lock()
+	if song.player == nil {
+		song.mu.Unlock()
+		return
+	}
+	song.paused = false
+	song.player.Play()
+	song.mu.Unlock()
+	song.publish(events, messages.TrackResumed, nil)
+}
+
+func (song *Song) Pause(events *messages.Bus) {
+	song.mu.Lock()
+	if song.player == nil {
+		song.mu.Unlock()
+		return
+	}
+	song.paused = true
+	song.player.Pause()
+	song.mu.Unlock()
+	song.publish(events, messages.TrackPaused, nil)
+}
+
+func (song *Song) publish(events *messages.Bus, kind messages.EventKind, err error) {
+	events.Publish(messages.Event{
+		Kind:     kind,
+		SongName: song.Name,
+		FilePath: song.FilePath,
+		Position: song.Position(),
+		Err:      err,
+	})
+}
+
+// How far into the song playback is, 0 if it isn't playing
+func (song *Song) Position() time.Duration {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	if song.player == nil {
+		return 0
 	}
+	return song.player.Position()
 }
 
-func (song *Song) Resume(songControlsChan chan messages.ChannelMessage) {
-	song.Player.Play()
-	fmt.Println("Publishing resume message...")
-	songControlsChan <- messages.ChannelMessage{SongResumed: true}
-	fmt.Println("Published resume message...")
+// Length of the song, 0 if it isn't playing or the length isn't known
+func (song *Song) Duration() time.Duration {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	if song.player == nil {
+		return song.length
+	}
+	return song.player.Duration()
 }
 
-func (song *Song) Pause(songControlsChan chan messages.ChannelMessage) {
-	song.Player.Pause()
-	fmt.Println("Publishing pause message...")
-	songControlsChan <- messages.ChannelMessage{SongPaused: true}
-	fmt.Println("Published pause message...")
+// Jumps to the given point in the song if it is playing
+func (song *Song) Seek(position time.Duration) error {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	if song.player == nil {
+		return nil
+	}
+	return song.player.Seek(position)
+}
+
+// Changes the volume, straight away if the song is already playing
+func (song *Song) SetVolume(volume float64) {
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	song.volume = volume
+	if song.player != nil {
+		song.player.SetVolume(volume)
+	}
 }
 
 func (song *Song) Stop(skipped bool) {
-	if song.Player != nil {
-		song.Player.Close()
+	song.mu.Lock()
+	defer song.mu.Unlock()
+	song.stopLocked(skipped)
+}
+
+func (song *Song) stopLocked(skipped bool) {
+	if song.player != nil {
+		song.player.Close()
 	}
-	song.Player = nil
-	song.Skipped = skipped
+	song.player = nil
+	song.skipped = skipped
 }
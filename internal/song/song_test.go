@@ -0,0 +1,87 @@
+package song
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	// Internal imports
+	"pomogoro/internal/audio"
+	"pomogoro/internal/audio/audiotest"
+	"pomogoro/internal/messages"
+)
+
+// Plays the song in real time while other goroutines keep reading and changing it, returning how playing it ended
+func playWhilePolling(t *testing.T, song *Song, during func()) messages.EventKind {
+	t.Helper()
+	events := messages.NewBus()
+	defer events.Close()
+	ended := make(chan messages.EventKind, 1)
+	events.Subscribe(func(event messages.Event) {
+		if event.Kind != messages.TrackStarted {
+			ended <- event.Kind
+		}
+	})
+
+	done := make(chan struct{})
+	var polling sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		polling.Add(1)
+		go func() {
+			defer polling.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				song.Position()
+				song.Duration()
+				song.IsPlaying()
+				song.SetVolume(0.5)
+			}
+		}()
+	}
+
+	go song.Play(audio.NewNullOutput(audio.DefaultSampleRate), events)
+	during()
+	defer func() {
+		close(done)
+		polling.Wait()
+	}()
+	select {
+	case kind := <-ended:
+		return kind
+	case <-time.After(5 * time.Second):
+		t.Fatal("song never ended")
+		return 0
+	}
+}
+
+func TestSongPlaysToTheEndWhileBeingPolled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "song.wav")
+	audiotest.WriteWAV(t, path, 300*time.Millisecond)
+	song := NewSong(filepath.Dir(path), filepath.Base(path))
+
+	if kind := playWhilePolling(t, song, func() {}); kind != messages.TrackFinished {
+		t.Fatalf("song ended with %v, want %v", kind, messages.TrackFinished)
+	}
+	if song.IsLoaded() {
+		t.Fatal("song is still loaded after finishing")
+	}
+}
+
+func TestSongStoppedWhileBeingPolled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "song.wav")
+	audiotest.WriteWAV(t, path, 5*time.Second)
+	song := NewSong(filepath.Dir(path), filepath.Base(path))
+
+	kind := playWhilePolling(t, song, func() {
+		time.Sleep(150 * time.Millisecond)
+		song.Stop(false)
+	})
+	if kind != messages.TrackStopped {
+		t.Fatalf("song ended with %v, want %v", kind, messages.TrackStopped)
+	}
+}
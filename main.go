@@ -1,9 +1,19 @@
 package main
 
 import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
 	// Internal imports
+	"pomogoro/internal/audio"
+	"pomogoro/internal/audio/speaker"
+	"pomogoro/internal/clock"
 	"pomogoro/internal/gui"
+	"pomogoro/internal/history"
 	"pomogoro/internal/library"
+	"pomogoro/internal/messages"
 	"pomogoro/internal/player"
 	"pomogoro/internal/pomoapp"
 	"pomogoro/internal/pomodoro"
@@ -12,6 +22,7 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 )
@@ -19,21 +30,23 @@ import (
 // TODO(map) List of things to correct
 // * Ship with the settings stored within the application itself? This would make them not persistant though
 // * Figure out a nice way to introduce playing music
-// * Fill circle based on percentage of time ran
 // * Don't allow for going over the total number of iterations
-// * Add ability to save timer and link playlist
+// * Link playlists to saved timers once playlists exist
 // * Toggle text of the button between play and pause
 // * Link playlists to the focus and relax timer
 // * Save setting to store whether music should pause during the relax timer
-// * Refresh library when changed
 
 const (
-	settingsFilePath = "/home/michael/Desktop/programming/pomogoro/settings.json"
-	// TODO(map) Implement the saved Pomodoros
-	// savedPomodoros = "/home/michael/Desktop/programming/pomogoro/saved_pomodoros.json"
+	settingsFilePath       = "/home/michael/Desktop/programming/pomogoro/settings.json"
+	savedPomodorosFilePath = "/home/michael/Desktop/programming/pomogoro/saved_pomodoros.json"
+	defaultLibraryPath     = "/home/michael/Desktop/programming/pomogoro/library" // Used until library folders are set
+
+	// Kept in the application data directory
+	historyFileName      = "history.jsonl"
+	libraryCacheFileName = "library_cache.json"
 
 	// Sizes
-	width  = 800
+	width  = 1100
 	height = 600
 
 	// Text
@@ -46,32 +59,121 @@ const (
 	pauseButtonText      = "Pause"
 	nextButtonText       = "Next"
 	libraryListLabelText = "Library:"
+	queueLabelText       = "Up Next:"
 	detailsLabelText     = "Song Details"
+	currentlyPlayingText = "Currently Playing:"
+	couldNotPlayText     = "Could not play %s, skipping it"
 )
 
 var settings = pomoapp.NewSettings(settingsFilePath, "", false, false, false)
+var presets = pomodoro.NewPresetStore(savedPomodorosFilePath)
 
 func main() {
 	// Load the settings for the application
 	settings.Load()
 
+	// Load the saved pomodoros
+	if err := presets.Load(); err != nil {
+		log.Print("Failure in loading the saved pomodoros: ", err)
+	}
+
 	// Load library
-	library := library.Library{}
-	library.LoadLibrary("/home/michael/Desktop/programming/pomogoro/library", settings)
+	if len(settings.Roots()) == 0 {
+		settings.LibraryRoots = []string{defaultLibraryPath}
+	}
+	// Remember what is in the library between launches so only new or changed files have to be read
+	var libraryCache *library.MetadataCache
+	if dataDir, err := pomoapp.DataDir(); err != nil {
+		log.Print("Failure in finding the data directory, the whole library will be read every launch: ", err)
+	} else {
+		libraryCache = library.NewMetadataCache(filepath.Join(dataDir, libraryCacheFileName))
+		if err := libraryCache.Load(); err != nil {
+			log.Print("Failure in loading the library cache: ", err)
+		}
+	}
+	library := library.Library{Cache: libraryCache}
+	libraryErr := library.LoadLibrary(settings)
+	if libraryErr != nil {
+		log.Print("Failure in loading the library: ", libraryErr)
+	}
+
+	// Pick up songs being added, removed or retagged while the app is open
+	watcher, err := library.Watch(settings)
+	if err != nil {
+		log.Print("Failure in watching the library folders, changes will need a restart: ", err)
+	} else {
+		defer watcher.Close()
+	}
+
+	// Open the sound card once for the whole app, falling back to a silent output so the rest still works without one
+	var output audio.AudioOutput
+	otoOutput, err := speaker.NewOtoOutput(audio.DefaultSampleRate)
+	if err != nil {
+		log.Print("Failure in opening the audio output, music will not be heard: ", err)
+		output = audio.NewNullOutput(audio.DefaultSampleRate)
+	} else {
+		output = otoOutput
+	}
 
 	// Load the player
-	player := player.Player{IsPlaying: false, IsPaused: false}
+	player := player.NewPlayer(output, settings.EffectiveVolume())
+	defer player.Close()
 
 	myApp := app.New()
 	window := myApp.NewWindow(titleText)
-	pomodoroTimer := pomodoro.NewPomodoroTimer(&library, settings)
+	pomodoroTimer := pomodoro.NewPomodoroTimer(clock.NewRealClock())
+
+	// Record what the timer and player get up to so the session can be reviewed later
+	var historyStore *history.Store
+	if dataDir, err := pomoapp.DataDir(); err != nil {
+		log.Print("Failure in finding the data directory, history will not be recorded: ", err)
+	} else {
+		historyStore = history.NewStore(filepath.Join(dataDir, historyFileName))
+		historyRecorder := history.NewRecorder(historyStore)
+		pomodoroTimer.SubscribeEvents(historyRecorder.Record)
+		player.Events.Subscribe(historyRecorder.Observe)
+	}
+
+	pomodoroTimerCanvas := gui.NewPomodoroTimerCanvas(pomodoroTimer, settings)
+	pomodoroTimer.SubscribeSessionComplete(func(state pomodoro.State) {
+		myApp.SendNotification(fyne.NewNotification(
+			titleText,
+			fmt.Sprintf("Session complete! You finished all %d iterations.", state.Iterations),
+		))
+	})
+	if preset, ok := presets.Default(); ok {
+		pomodoroTimer.ApplyPreset(preset)
+	}
 
 	// Toolbar
-	toolbar := gui.CreateNewToolbar(myApp, pomodoroTimer, settings)
+	refreshLibrary := func() {
+		// New folders or patterns in the settings change what is in the library and which folders need watching
+		var err error
+		if watcher != nil {
+			err = watcher.Refresh()
+		} else {
+			err = library.Refresh(settings)
+		}
+		if err != nil {
+			dialog.ShowError(err, window)
+		}
+	}
+	toolbar := gui.CreateNewToolbar(myApp, pomodoroTimer, settings, presets, historyStore, refreshLibrary)
 
 	// About info
 	descriptionLabel := widget.NewLabel(descriptionText)
-	currentSongPlaying := widget.NewLabel("Currently Playing:")
+	currentSongPlaying := widget.NewLabel(currentlyPlayingText)
+	player.Events.Subscribe(func(event messages.Event) {
+		switch event.Kind {
+		case messages.TrackStarted:
+			currentSongPlaying.SetText(currentlyPlayingText + " " + event.SongName)
+		case messages.TrackFinished, messages.TrackStopped:
+			currentSongPlaying.SetText(currentlyPlayingText)
+		case messages.TrackError:
+			// The player moves on by itself so this is only worth a mention rather than a dialog
+			currentSongPlaying.SetText(fmt.Sprintf(couldNotPlayText, event.SongName))
+		}
+	})
 	descriptionLabelContainer := container.New(
 		layout.NewGridWrapLayout(fyne.NewSize(300, 50)),
 		descriptionLabel,
@@ -82,10 +184,24 @@ func main() {
 	)
 
 	// Song details view
-	songDetailsView := gui.NewSongDetailsView(detailsLabelText)
+	songDetailsView := gui.NewSongDetailsView(detailsLabelText, window)
 
 	// Library View
-	libraryView := gui.NewLibraryView(libraryListLabelText, &library, songDetailsView, settings, &player)
+	rescanLibrary := func() error {
+		if watcher != nil {
+			return watcher.Rescan()
+		}
+		return library.Rescan(settings)
+	}
+	libraryView := gui.NewLibraryView(libraryListLabelText, &library, songDetailsView, player, window, rescanLibrary)
+
+	// Queue View
+	queueView := gui.NewQueueView(queueLabelText, library.Queue)
+	libraryRow := container.New(
+		layout.NewHBoxLayout(),
+		libraryView.Container,
+		queueView.Container,
+	)
 
 	// Info
 	descriptionRow := container.New(
@@ -94,19 +210,35 @@ func main() {
 		currentSongPlayingContainer,
 	)
 	// Control
-	controls := gui.NewMusicControls(&library, &player, settings, pomodoroTimer)
+	controls := gui.NewMusicControls(&library, player, settings, pomodoroTimer)
+	controls.AddShortcuts(window)
 
 	// Parent container
 	content := container.New(
 		layout.NewVBoxLayout(),
 		toolbar,
-		pomodoroTimer.PomodoroTimerCanvas.TopLevelContainer,
+		pomodoroTimerCanvas.TopLevelContainer,
 		descriptionRow,
-		libraryView.Container,
+		libraryRow,
 		controls.Container,
 	)
 
 	window.SetContent(content)
 	window.Resize(fyne.NewSize(width, height))
+
+	// Let the user know if there is no music to play
+	if libraryErr != nil {
+		dialog.ShowError(libraryErr, window)
+	}
+
+	// Let the user know about anything in the library that won't show up
+	if len(library.SkippedFiles()) > 0 {
+		dialog.ShowInformation(
+			"Unsupported Files Skipped",
+			"These files are not in a supported format (MP3, WAV, FLAC or Ogg Vorbis):\n"+
+				strings.Join(library.SkippedFiles(), "\n"),
+			window,
+		)
+	}
 	window.ShowAndRun()
 }